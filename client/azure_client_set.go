@@ -6,8 +6,11 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/profiles/latest/compute/mgmt/compute"
 	"github.com/Azure/azure-sdk-for-go/profiles/latest/graphrbac/graphrbac"
-	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-11-01/network"     //nolint:staticcheck
-	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources" //nolint:staticcheck
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-11-01/network"          //nolint:staticcheck
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2019-06-01/insights" //nolint:staticcheck
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2016-09-01/locks"          //nolint:staticcheck
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"      //nolint:staticcheck
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2019-06-01/storage"          //nolint:staticcheck
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
@@ -31,12 +34,32 @@ const (
 // AzureClientSet is the collection of Azure API clients.
 type AzureClientSet struct {
 	ApplicationsClient *graphrbac.ApplicationsClient
+	// DeploymentOperationsClient lists the operations of deployments of ARM templates.
+	DeploymentOperationsClient *resources.DeploymentOperationsClient
 	// DeploymentsClient manages deployments of ARM templates.
 	DeploymentsClient *resources.DeploymentsClient
+	// DisksClient manages managed disks.
+	DisksClient *compute.DisksClient
 	// GroupsClient manages ARM resource groups.
 	GroupsClient *resources.GroupsClient
+	// InterfacesClient manages network interfaces.
+	InterfacesClient *network.InterfacesClient
 	// LoadBalancersClient manages Load Balancer resources.
 	LoadBalancersClient *network.LoadBalancersClient
+	// ManagementLocksClient manages locks of ARM resources.
+	ManagementLocksClient *locks.ManagementLocksClient
+	// MetricsClient reads Azure Monitor platform metrics of resources.
+	MetricsClient *insights.MetricsClient
+	// NatGatewaysClient manages NAT gateways.
+	NatGatewaysClient *network.NatGatewaysClient
+	// NetworkUsagesClient is used to work with network limits and quotas.
+	NetworkUsagesClient *network.UsagesClient
+	// PublicIPAddressesClient manages public IP addresses.
+	PublicIPAddressesClient *network.PublicIPAddressesClient
+	// ResourcesClient manages ARM resources of any type.
+	ResourcesClient *resources.Client
+	// StorageUsagesClient is used to work with storage limits and quotas.
+	StorageUsagesClient *storage.UsagesClient
 	// UsageClient is used to work with limits and quotas.
 	UsageClient *compute.UsageClient
 	// VirtualNetworkGatewayConnectionsClient manages virtual network gateway connections.
@@ -77,18 +100,58 @@ func NewAzureClientSet(config AzureClientSetConfig) (*AzureClientSet, error) {
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
+	deploymentOperationsClient, err := newDeploymentOperationsClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 	deploymentsClient, err := newDeploymentsClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
+	disksClient, err := newDisksClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 	groupsClient, err := newGroupsClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
+	interfacesClient, err := newInterfacesClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 	loadBalancersClient, err := newLoadBalancersClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
+	managementLocksClient, err := newManagementLocksClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	metricsClient, err := newMetricsClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	natGatewaysClient, err := newNatGatewaysClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	networkUsagesClient, err := newNetworkUsagesClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	publicIPAddressesClient, err := newPublicIPAddressesClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	resourcesClient, err := newResourcesClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	storageUsagesClient, err := newStorageUsagesClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 	usageClient, err := newUsageClient(config.Authorizer, config.SubscriptionID, config.PartnerID)
 	if err != nil {
 		return nil, microerror.Mask(err)
@@ -104,9 +167,19 @@ func NewAzureClientSet(config AzureClientSetConfig) (*AzureClientSet, error) {
 
 	clientSet := &AzureClientSet{
 		ApplicationsClient:                     applicationsClient,
+		DeploymentOperationsClient:             deploymentOperationsClient,
 		DeploymentsClient:                      deploymentsClient,
+		DisksClient:                            disksClient,
 		GroupsClient:                           groupsClient,
+		InterfacesClient:                       interfacesClient,
 		LoadBalancersClient:                    loadBalancersClient,
+		ManagementLocksClient:                  managementLocksClient,
+		MetricsClient:                          metricsClient,
+		NatGatewaysClient:                      natGatewaysClient,
+		NetworkUsagesClient:                    networkUsagesClient,
+		PublicIPAddressesClient:                publicIPAddressesClient,
+		ResourcesClient:                        resourcesClient,
+		StorageUsagesClient:                    storageUsagesClient,
 		UsageClient:                            usageClient,
 		VirtualNetworkGatewayConnectionsClient: virtualNetworkGatewayConnectionsClient,
 		VirtualMachineScaleSetVMsClient:        virtualMachineScaleSetVMsClient,
@@ -122,6 +195,13 @@ func prepareClient(client *autorest.Client, authorizer autorest.Authorizer, part
 	return client
 }
 
+func newDeploymentOperationsClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*resources.DeploymentOperationsClient, error) {
+	client := resources.NewDeploymentOperationsClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
 func newDeploymentsClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*resources.DeploymentsClient, error) {
 	client := resources.NewDeploymentsClient(subscriptionID)
 	prepareClient(&client.Client, authorizer, partnerID)
@@ -129,6 +209,13 @@ func newDeploymentsClient(authorizer autorest.Authorizer, subscriptionID, partne
 	return &client, nil
 }
 
+func newDisksClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*compute.DisksClient, error) {
+	client := compute.NewDisksClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
 func newGroupsClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*resources.GroupsClient, error) {
 	client := resources.NewGroupsClient(subscriptionID)
 	prepareClient(&client.Client, authorizer, partnerID)
@@ -136,6 +223,13 @@ func newGroupsClient(authorizer autorest.Authorizer, subscriptionID, partnerID s
 	return &client, nil
 }
 
+func newInterfacesClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*network.InterfacesClient, error) {
+	client := network.NewInterfacesClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
 func newLoadBalancersClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*network.LoadBalancersClient, error) {
 	client := network.NewLoadBalancersClient(subscriptionID)
 	prepareClient(&client.Client, authorizer, partnerID)
@@ -143,6 +237,55 @@ func newLoadBalancersClient(authorizer autorest.Authorizer, subscriptionID, part
 	return &client, nil
 }
 
+func newManagementLocksClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*locks.ManagementLocksClient, error) {
+	client := locks.NewManagementLocksClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
+func newMetricsClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*insights.MetricsClient, error) {
+	client := insights.NewMetricsClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
+func newNatGatewaysClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*network.NatGatewaysClient, error) {
+	client := network.NewNatGatewaysClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
+func newNetworkUsagesClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*network.UsagesClient, error) {
+	client := network.NewUsagesClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
+func newPublicIPAddressesClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*network.PublicIPAddressesClient, error) {
+	client := network.NewPublicIPAddressesClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
+func newResourcesClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*resources.Client, error) {
+	client := resources.NewClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
+func newStorageUsagesClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*storage.UsagesClient, error) {
+	client := storage.NewUsagesClient(subscriptionID)
+	prepareClient(&client.Client, authorizer, partnerID)
+
+	return &client, nil
+}
+
 func newUsageClient(authorizer autorest.Authorizer, subscriptionID, partnerID string) (*compute.UsageClient, error) {
 	client := compute.NewUsageClient(subscriptionID)
 	prepareClient(&client.Client, authorizer, partnerID)
@@ -0,0 +1,5 @@
+package collectors
+
+type Collectors struct {
+	Disabled string
+}
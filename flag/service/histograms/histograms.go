@@ -0,0 +1,6 @@
+package histograms
+
+type Histograms struct {
+	ConfigMapName      string
+	ConfigMapNamespace string
+}
@@ -0,0 +1,7 @@
+package leaderelection
+
+type LeaderElection struct {
+	Enabled   string
+	LeaseName string
+	Namespace string
+}
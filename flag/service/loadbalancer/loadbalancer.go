@@ -0,0 +1,5 @@
+package loadbalancer
+
+type LoadBalancer struct {
+	Names string
+}
@@ -0,0 +1,7 @@
+package reload
+
+type Reload struct {
+	ConfigMapName      string
+	ConfigMapNamespace string
+	Interval           string
+}
@@ -0,0 +1,5 @@
+package resourcegroup
+
+type ResourceGroup struct {
+	TagKeys string
+}
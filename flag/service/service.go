@@ -4,11 +4,27 @@ import (
 	"github.com/giantswarm/operatorkit/v2/pkg/flag/service/kubernetes"
 
 	"github.com/giantswarm/azure-collector/v3/flag/service/azure"
+	"github.com/giantswarm/azure-collector/v3/flag/service/collectors"
+	"github.com/giantswarm/azure-collector/v3/flag/service/histograms"
+	"github.com/giantswarm/azure-collector/v3/flag/service/leaderelection"
+	"github.com/giantswarm/azure-collector/v3/flag/service/loadbalancer"
+	"github.com/giantswarm/azure-collector/v3/flag/service/reload"
+	"github.com/giantswarm/azure-collector/v3/flag/service/resourcegroup"
+	"github.com/giantswarm/azure-collector/v3/flag/service/sharding"
+	"github.com/giantswarm/azure-collector/v3/flag/service/usage"
 )
 
 type Service struct {
 	Azure                     azure.Azure
+	Collectors                collectors.Collectors
 	ControlPlaneResourceGroup string
+	Histograms                histograms.Histograms
 	Kubernetes                kubernetes.Kubernetes
+	LeaderElection            leaderelection.LeaderElection
+	LoadBalancer              loadbalancer.LoadBalancer
 	Location                  string
+	Reload                    reload.Reload
+	ResourceGroup             resourcegroup.ResourceGroup
+	Sharding                  sharding.Sharding
+	Usage                     usage.Usage
 }
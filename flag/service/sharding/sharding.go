@@ -0,0 +1,7 @@
+package sharding
+
+type Sharding struct {
+	Enabled     string
+	LeasePrefix string
+	Namespace   string
+}
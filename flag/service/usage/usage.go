@@ -0,0 +1,5 @@
+package usage
+
+type Usage struct {
+	Locations string
+}
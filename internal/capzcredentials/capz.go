@@ -2,6 +2,7 @@ package capzcredentials
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/giantswarm/microerror"
 	v1 "k8s.io/api/core/v1"
@@ -55,6 +56,7 @@ func getCapzCredentials(ctx context.Context, ctrlClient client.Client, obj metav
 		TenantID:       identity.Spec.TenantID,
 		ClientID:       identity.Spec.ClientID,
 		ClientSecret:   string(secret.Data["clientSecret"]),
+		Source:         fmt.Sprintf("AzureClusterIdentity %s/%s", identity.Namespace, identity.Name),
 	}, nil
 }
 
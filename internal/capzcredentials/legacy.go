@@ -2,6 +2,7 @@ package capzcredentials
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	apiextensionslabels "github.com/giantswarm/apiextensions/v6/pkg/label"
@@ -58,6 +59,7 @@ func getLegacyCredentials(ctx context.Context, ctrlClient client.Client, objectM
 		TenantID:       tenantID,
 		ClientID:       clientID,
 		ClientSecret:   clientSecret,
+		Source:         fmt.Sprintf("Secret %s/%s", secret.Namespace, secret.Name),
 	}, nil
 
 }
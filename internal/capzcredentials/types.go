@@ -5,4 +5,7 @@ type AzureCredentials struct {
 	TenantID       string
 	ClientID       string
 	ClientSecret   string
+	// Source describes where the credentials were found, e.g. the
+	// AzureClusterIdentity or the credential secret.
+	Source string
 }
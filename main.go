@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/giantswarm/microerror"
@@ -11,6 +13,7 @@ import (
 	microserver "github.com/giantswarm/microkit/server"
 	"github.com/giantswarm/micrologger"
 	"github.com/giantswarm/versionbundle"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	"github.com/giantswarm/azure-collector/v3/pkg/project"
@@ -38,12 +41,19 @@ func main() {
 func mainError() error {
 	var err error
 
-	ctx := context.Background()
+	// The context is canceled on shutdown, so the service is able to clean up,
+	// e.g. delete its shard member lease.
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
 	logger, err := micrologger.New(micrologger.Config{})
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
+	// flags are the flags of the daemon command, which are parsed before the
+	// server factory is called.
+	var flags *pflag.FlagSet
+
 	// We define a server factory to create the custom server once all command
 	// line flags are parsed and all microservice configuration is sorted out.
 	serverFactory := func(v *viper.Viper) microserver.Server {
@@ -52,6 +62,7 @@ func mainError() error {
 		{
 			c := service.Config{
 				Flag:   f,
+				Flags:  flags,
 				Logger: logger,
 				Viper:  v,
 
@@ -112,14 +123,30 @@ func mainError() error {
 	}
 
 	daemonCommand := newCommand.DaemonCommand().CobraCommand()
+	flags = daemonCommand.Flags()
 
 	daemonCommand.PersistentFlags().String(f.Service.Azure.ClientID, "", "ID of the Active Directory Service Principal.")
 	daemonCommand.PersistentFlags().String(f.Service.Azure.ClientSecret, "", "Secret of the Active Directory Service Principal.")
 	daemonCommand.PersistentFlags().String(f.Service.Azure.PartnerID, "", "Partner id used in Azure for the attribution partner program.")
 	daemonCommand.PersistentFlags().String(f.Service.Azure.SubscriptionID, "", "ID of the Azure Subscription.")
 	daemonCommand.PersistentFlags().String(f.Service.Azure.TenantID, "", "ID of the Active Directory Tenant.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Collectors.Disabled, nil, "Names of the collectors which are not run, e.g. usage or resource_group.")
 	daemonCommand.PersistentFlags().String(f.Service.ControlPlaneResourceGroup, "", "Control plane resource group name.")
 	daemonCommand.PersistentFlags().String(f.Service.Location, "westeurope", "Azure location of the host and guset clusters.")
+	daemonCommand.PersistentFlags().String(f.Service.Histograms.ConfigMapName, "", "Name of the ConfigMap persisting the histograms of cluster creation and upgrade durations. When empty the histograms are reset on restart.")
+	daemonCommand.PersistentFlags().String(f.Service.Histograms.ConfigMapNamespace, "giantswarm", "Namespace of the ConfigMap persisting the histograms of cluster creation and upgrade durations.")
+	daemonCommand.PersistentFlags().Bool(f.Service.LeaderElection.Enabled, false, "Whether only an elected leader among the replicas polls the Azure APIs.")
+	daemonCommand.PersistentFlags().String(f.Service.LeaderElection.LeaseName, "azure-collector", "Name of the Lease used for the leader election.")
+	daemonCommand.PersistentFlags().String(f.Service.LeaderElection.Namespace, "giantswarm", "Namespace of the Lease used for the leader election.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.LoadBalancer.Names, nil, "Names of the load balancers collected in the resource group of every cluster. Defaults to kubernetes and kubernetes-internal. Use * to collect all load balancers in the resource group.")
+	daemonCommand.PersistentFlags().String(f.Service.Reload.ConfigMapName, "", "Name of a ConfigMap with overrides of the config files, polled for changed settings. It must not be the ConfigMap mounted as config file. When empty only the config files are watched.")
+	daemonCommand.PersistentFlags().String(f.Service.Reload.ConfigMapNamespace, "giantswarm", "Namespace of the ConfigMap polled for changed settings.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Reload.Interval, time.Minute, "Interval of polling the ConfigMap for changed settings.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.ResourceGroup.TagKeys, nil, "Tag keys of resource groups exposed as labels of azure_operator_resource_group_tags.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Sharding.Enabled, false, "Whether clusters and subscriptions are shared across the replicas.")
+	daemonCommand.PersistentFlags().String(f.Service.Sharding.LeasePrefix, "azure-collector-shard", "Name prefix of the Leases announcing the replicas sharing clusters and subscriptions.")
+	daemonCommand.PersistentFlags().String(f.Service.Sharding.Namespace, "giantswarm", "Namespace of the Leases announcing the replicas sharing clusters and subscriptions.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Usage.Locations, nil, "Azure locations to collect quota usages for in every subscription. When empty the locations of the clusters in each subscription are used.")
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.Address, "", "Address used to connect to Kubernetes. When empty in-cluster config is created.")
 	daemonCommand.PersistentFlags().Bool(f.Service.Kubernetes.InCluster, true, "Whether to use the in-cluster config to authenticate with Kubernetes.")
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.KubeConfig, "", "KubeConfig used to connect to Kubernetes. When empty other settings are used.")
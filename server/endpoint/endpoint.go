@@ -6,6 +6,7 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 
+	"github.com/giantswarm/azure-collector/v3/server/endpoint/inventory"
 	"github.com/giantswarm/azure-collector/v3/service"
 )
 
@@ -16,8 +17,11 @@ type Config struct {
 
 // Endpoint is the endpoint collection.
 type Endpoint struct {
-	Healthz *healthz.Endpoint
-	Version *versionendpoint.Endpoint
+	Healthz   *healthz.Endpoint
+	Inventory *inventory.Endpoint
+	Liveness  *Probe
+	Readiness *Probe
+	Version   *versionendpoint.Endpoint
 }
 
 func New(config Config) (*Endpoint, error) {
@@ -35,6 +39,29 @@ func New(config Config) (*Endpoint, error) {
 		}
 	}
 
+	var inventoryEndpoint *inventory.Endpoint
+	{
+		c := inventory.Config{
+			Logger:    config.Logger,
+			Inventory: config.Service.Inventory,
+		}
+
+		inventoryEndpoint, err = inventory.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	livenessEndpoint, err := newProbe(config.Logger, config.Service.Liveness, livenessName, livenessPath)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	readinessEndpoint, err := newProbe(config.Logger, config.Service.Readiness, readinessName, readinessPath)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	var versionEndpoint *versionendpoint.Endpoint
 	{
 		c := versionendpoint.Config{
@@ -49,8 +76,11 @@ func New(config Config) (*Endpoint, error) {
 	}
 
 	newEndpoint := &Endpoint{
-		Healthz: healthzEndpoint,
-		Version: versionEndpoint,
+		Healthz:   healthzEndpoint,
+		Inventory: inventoryEndpoint,
+		Liveness:  livenessEndpoint,
+		Readiness: readinessEndpoint,
+		Version:   versionEndpoint,
 	}
 
 	return newEndpoint, nil
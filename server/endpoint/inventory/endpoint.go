@@ -0,0 +1,106 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+)
+
+const (
+	// Method is the HTTP method this endpoint is registered for.
+	Method = "GET"
+	// Name identifies the endpoint. It is aligned to the package path.
+	Name = "inventory"
+	// Path is the HTTP request path this endpoint is registered for.
+	Path = "/inventory"
+)
+
+// Config represents the configuration used to create an inventory endpoint.
+type Config struct {
+	Logger    micrologger.Logger
+	Inventory *inventory.Inventory
+}
+
+// Endpoint lists every cluster the collector discovered together with the
+// credentials used for it and the outcome of the collectors running for it.
+type Endpoint struct {
+	logger    micrologger.Logger
+	inventory *inventory.Inventory
+}
+
+// New creates a new configured inventory endpoint.
+func New(config Config) (*Endpoint, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
+
+	e := &Endpoint{
+		logger:    config.Logger,
+		inventory: config.Inventory,
+	}
+
+	return e, nil
+}
+
+func (e *Endpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		return nil, nil
+	}
+}
+
+func (e *Endpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e *Endpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response := Response{
+			Clusters:      e.inventory.Clusters(),
+			Subscriptions: e.inventory.Subscriptions(),
+		}
+
+		syncedAt := e.inventory.SyncedAt()
+		if !syncedAt.IsZero() {
+			response.SyncedAt = &syncedAt
+		}
+
+		if response.Clusters == nil {
+			response.Clusters = []inventory.Cluster{}
+		}
+		if response.Subscriptions == nil {
+			response.Subscriptions = []inventory.Subscription{}
+		}
+
+		return response, nil
+	}
+}
+
+func (e *Endpoint) Method() string {
+	return Method
+}
+
+func (e *Endpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e *Endpoint) Name() string {
+	return Name
+}
+
+func (e *Endpoint) Path() string {
+	return Path
+}
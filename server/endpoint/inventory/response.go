@@ -0,0 +1,18 @@
+package inventory
+
+import (
+	"time"
+
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+)
+
+// Response is the return value of the inventory endpoint.
+type Response struct {
+	// SyncedAt is the time the clusters were discovered the last time. It is
+	// omitted as long as no discovery finished.
+	SyncedAt *time.Time          `json:"syncedAt,omitempty"`
+	Clusters []inventory.Cluster `json:"clusters"`
+	// Subscriptions holds the results of the collectors working on whole
+	// subscriptions instead of single clusters.
+	Subscriptions []inventory.Subscription `json:"subscriptions"`
+}
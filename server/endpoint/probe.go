@@ -0,0 +1,80 @@
+package endpoint
+
+import (
+	"github.com/giantswarm/microendpoint/endpoint/healthz"
+	healthzservice "github.com/giantswarm/microendpoint/service/healthz"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+)
+
+const (
+	livenessName = "livez"
+	livenessPath = "/livez"
+
+	readinessName = "readyz"
+	readinessPath = "/readyz"
+)
+
+// Probe is a healthz endpoint registered under its own name and path, so that
+// Kubernetes liveness and readiness probes can check different services.
+type Probe struct {
+	healthz *healthz.Endpoint
+
+	name string
+	path string
+}
+
+func newProbe(logger micrologger.Logger, service healthzservice.Service, name, path string) (*Probe, error) {
+	if service == nil {
+		return nil, microerror.Maskf(invalidConfigError, "service for probe %#q must not be empty", name)
+	}
+
+	c := healthz.Config{
+		Logger:   logger,
+		Services: []healthzservice.Service{service},
+	}
+
+	e, err := healthz.New(c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	p := &Probe{
+		healthz: e,
+
+		name: name,
+		path: path,
+	}
+
+	return p, nil
+}
+
+func (p *Probe) Decoder() kithttp.DecodeRequestFunc {
+	return p.healthz.Decoder()
+}
+
+func (p *Probe) Encoder() kithttp.EncodeResponseFunc {
+	return p.healthz.Encoder()
+}
+
+func (p *Probe) Endpoint() kitendpoint.Endpoint {
+	return p.healthz.Endpoint()
+}
+
+func (p *Probe) Method() string {
+	return p.healthz.Method()
+}
+
+func (p *Probe) Middlewares() []kitendpoint.Middleware {
+	return p.healthz.Middlewares()
+}
+
+func (p *Probe) Name() string {
+	return p.name
+}
+
+func (p *Probe) Path() string {
+	return p.path
+}
@@ -65,6 +65,9 @@ func New(config Config) (microserver.Server, error) {
 
 			Endpoints: []microserver.Endpoint{
 				endpointCollection.Healthz,
+				endpointCollection.Inventory,
+				endpointCollection.Liveness,
+				endpointCollection.Readiness,
 				endpointCollection.Version,
 			},
 			ErrorEncoder: encodeError,
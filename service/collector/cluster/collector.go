@@ -3,36 +3,69 @@ package cluster
 import (
 	"context"
 
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
 )
 
 const (
 	MetricsNamespace = "azure_operator"
+
+	// aggregatesShardKey is the shard key of the metrics aggregated over all
+	// clusters, which are exposed by a single replica.
+	aggregatesShardKey = "aggregates"
 )
 
+// Leader tells whether this replica is the leader.
+type Leader interface {
+	IsLeader() bool
+}
+
 type Collectors struct {
+	name       string
 	ctrlClient client.Client
+	inventory  *inventory.Inventory
+	leader     Leader
 	logger     micrologger.Logger
+	shard      sharding.Interface
 
 	collectors []ClusterCollector
 }
 
-func NewCollectors(ctrlClient client.Client, logger micrologger.Logger) (*Collectors, error) {
+// NewCollectors runs the added cluster collectors for every cluster owned by
+// this shard. leader is optional. When set, the aggregates are only exposed
+// by the leader, since the collectors may run on every replica.
+func NewCollectors(name string, ctrlClient client.Client, inventory *inventory.Inventory, leader Leader, logger micrologger.Logger, shard sharding.Interface) (*Collectors, error) {
+	if name == "" {
+		return nil, microerror.Maskf(invalidConfigError, "name must not be empty")
+	}
 	if ctrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "ctrlClient must not be empty")
 	}
+	if inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "inventory must not be empty")
+	}
 	if logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "logger must not be empty")
 	}
+	if shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "shard must not be empty")
+	}
 
 	c := &Collectors{
+		name:       name,
 		ctrlClient: ctrlClient,
+		inventory:  inventory,
+		leader:     leader,
 		logger:     logger,
+		shard:      shard,
 	}
 
 	return c, nil
@@ -52,18 +85,103 @@ func (c *Collectors) Collect(ch chan<- prometheus.Metric) error {
 		}
 	}
 
+	capiClusters := map[string]bool{}
 	for _, cr := range clusters.Items {
+		capiClusters[cr.Name] = true
+
+		if !c.shard.Owns(cr.Name) {
+			continue
+		}
+
 		for _, collector := range c.collectors {
 			err := collector.Collect(ctx, &cr, ch) //nolint:gosec
+			c.inventory.Record(cr.Name, collector.Name(), err)
 			if err != nil {
 				return microerror.Mask(err)
 			}
 		}
 	}
 
+	err := c.collectVintage(ctx, capiClusters, ch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	expose := c.shard.Owns(aggregatesShardKey) && (c.leader == nil || c.leader.IsLeader())
+	for _, collector := range c.collectors {
+		ac, ok := collector.(AggregateCollector)
+		if !ok {
+			continue
+		}
+
+		err := ac.CollectAggregate(ctx, expose, ch)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
 	return nil
 }
 
+// collectVintage exposes the metrics of the collectors implementing
+// VintageClusterCollector for the vintage clusters. AzureConfigs of clusters
+// which also have a CAPI Cluster CR are skipped, since their metrics are
+// already exposed based on the Cluster CR.
+func (c *Collectors) collectVintage(ctx context.Context, capiClusters map[string]bool, ch chan<- prometheus.Metric) error {
+	var collectors []VintageClusterCollector
+	for _, collector := range c.collectors {
+		vc, ok := collector.(VintageClusterCollector)
+		if ok {
+			collectors = append(collectors, vc)
+		}
+	}
+
+	if len(collectors) == 0 {
+		return nil
+	}
+
+	var crs []providerv1alpha1.AzureConfig
+	{
+		mark := ""
+		page := 0
+		for page == 0 || len(mark) > 0 {
+			opts := client.ListOptions{
+				Continue: mark,
+			}
+			list := providerv1alpha1.AzureConfigList{}
+			err := c.ctrlClient.List(ctx, &list, &opts)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			crs = append(crs, list.Items...)
+
+			mark = list.Continue
+			page++
+		}
+	}
+
+	for _, cr := range crs {
+		if capiClusters[cr.Name] || !c.shard.Owns(cr.Name) {
+			continue
+		}
+
+		for _, collector := range collectors {
+			err := collector.CollectVintage(ctx, &cr, ch) //nolint:gosec
+			c.inventory.Record(cr.Name, collector.Name(), err)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Collectors) Name() string {
+	return c.name
+}
+
 func (c *Collectors) Describe(ch chan<- *prometheus.Desc) error {
 	for _, collector := range c.collectors {
 		err := collector.Describe(ch)
@@ -3,16 +3,30 @@ package cluster
 import (
 	"context"
 
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	aeconditions "github.com/giantswarm/apiextensions/v6/pkg/conditions"
 	"github.com/giantswarm/apiextensions/v6/pkg/label"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capzv1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capzexpv1beta1 "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1beta1"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	kcpv1beta1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
+	"sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	kindAzureCluster        = "AzureCluster"
+	kindAzureMachinePool    = "AzureMachinePool"
+	kindCluster             = "Cluster"
+	kindKubeadmControlPlane = "KubeadmControlPlane"
+	kindMachinePool         = "MachinePool"
+)
+
 type Conditions struct {
 	ctrlClient client.Client
 	logger     micrologger.Logger
@@ -29,8 +43,45 @@ var (
 		},
 		nil,
 	)
+
+	clusterCondition = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "cluster", "condition"),
+		"Conditions of the Cluster, AzureCluster, KubeadmControlPlane, MachinePool and AzureMachinePool CRs of a cluster. The value is always 1.",
+		[]string{
+			"cluster_id",
+			"kind",
+			"name",
+			"type",
+			"status",
+			"severity",
+			"reason",
+		},
+		nil,
+	)
+
+	clusterConditionLastTransition = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "cluster", "condition_last_transition_timestamp_seconds"),
+		"Last transition time of the conditions of the Cluster, AzureCluster, KubeadmControlPlane, MachinePool and AzureMachinePool CRs of a cluster.",
+		[]string{
+			"cluster_id",
+			"kind",
+			"name",
+			"type",
+		},
+		nil,
+	)
 )
 
+// condition is a CAPI condition independent of the API version of the CR
+// holding it.
+type condition struct {
+	Type               string
+	Status             string
+	Severity           string
+	Reason             string
+	LastTransitionTime metav1.Time
+}
+
 func NewConditions(ctrlClient client.Client, logger micrologger.Logger) (*Conditions, error) {
 	if ctrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "ctrlClient must not be empty")
@@ -48,6 +99,11 @@ func NewConditions(ctrlClient client.Client, logger micrologger.Logger) (*Condit
 }
 
 func (c *Conditions) Collect(ctx context.Context, cluster *capiv1beta1.Cluster, ch chan<- prometheus.Metric) error {
+	err := c.collectConditions(ctx, cluster, ch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
 	releaseVersion, ok := cluster.Labels[label.ReleaseVersion]
 	if !ok {
 		c.logger.Debugf(ctx, "Cluster %#q has no %#q label. Skipping", cluster.Name, label.ReleaseVersion)
@@ -85,5 +141,156 @@ func (c *Conditions) Collect(ctx context.Context, cluster *capiv1beta1.Cluster,
 
 func (c *Conditions) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- clusterStatus
+	ch <- clusterCondition
+	ch <- clusterConditionLastTransition
+	return nil
+}
+
+func (c *Conditions) Name() string {
+	return "conditions"
+}
+
+// CollectVintage exposes the Creating and Updating conditions of the
+// AzureConfig status as the Creating and Upgrading status of the cluster.
+func (c *Conditions) CollectVintage(ctx context.Context, cr *providerv1alpha1.AzureConfig, ch chan<- prometheus.Metric) error {
+	releaseVersion, ok := vintageReleaseVersion(cr)
+	if !ok {
+		c.logger.Debugf(ctx, "AzureConfig %#q has no %#q label nor version in its status. Skipping", cr.Name, label.ReleaseVersion)
+		return nil
+	}
+
+	var isCreating float64
+	if cr.Status.Cluster.HasCreatingCondition() {
+		isCreating = 1
+	}
+	ch <- prometheus.MustNewConstMetric(
+		clusterStatus,
+		prometheus.GaugeValue,
+		isCreating,
+		cr.Name,
+		releaseVersion,
+		string(aeconditions.CreatingCondition),
+	)
+
+	var isUpgrading float64
+	if cr.Status.Cluster.HasUpdatingCondition() {
+		isUpgrading = 1
+	}
+	ch <- prometheus.MustNewConstMetric(
+		clusterStatus,
+		prometheus.GaugeValue,
+		isUpgrading,
+		cr.Name,
+		releaseVersion,
+		string(aeconditions.UpgradingCondition),
+	)
+
+	return nil
+}
+
+// collectConditions exposes every condition of the CRs making up the given
+// cluster. CRs which cannot be fetched are skipped.
+func (c *Conditions) collectConditions(ctx context.Context, cluster *capiv1beta1.Cluster, ch chan<- prometheus.Metric) error {
+	emit := func(kind, name string, conditions []condition) {
+		for _, cond := range conditions {
+			ch <- prometheus.MustNewConstMetric(
+				clusterCondition,
+				prometheus.GaugeValue,
+				1,
+				cluster.Name,
+				kind,
+				name,
+				cond.Type,
+				cond.Status,
+				cond.Severity,
+				cond.Reason,
+			)
+
+			if cond.LastTransitionTime.IsZero() {
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				clusterConditionLastTransition,
+				prometheus.GaugeValue,
+				float64(cond.LastTransitionTime.Unix()),
+				cluster.Name,
+				kind,
+				name,
+				cond.Type,
+			)
+		}
+	}
+
+	emit(kindCluster, cluster.Name, fromV1beta1(cluster.Status.Conditions))
+
+	if ref := cluster.Spec.InfrastructureRef; ref != nil && ref.Kind == kindAzureCluster {
+		azureCluster := &capzv1beta1.AzureCluster{}
+		err := c.ctrlClient.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, azureCluster)
+		if err != nil {
+			c.logger.Errorf(ctx, err, "Unable to get AzureCluster for cluster %q", cluster.Name)
+		} else {
+			emit(kindAzureCluster, azureCluster.Name, fromV1beta1(azureCluster.Status.Conditions))
+		}
+	}
+
+	if ref := cluster.Spec.ControlPlaneRef; ref != nil && ref.Kind == kindKubeadmControlPlane {
+		kcp := &kcpv1beta1.KubeadmControlPlane{}
+		err := c.ctrlClient.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, kcp)
+		if err != nil {
+			c.logger.Errorf(ctx, err, "Unable to get KubeadmControlPlane for cluster %q", cluster.Name)
+		} else {
+			emit(kindKubeadmControlPlane, kcp.Name, fromV1beta1(kcp.Status.Conditions))
+		}
+	}
+
+	nps := v1alpha4.MachinePoolList{}
+	err := c.ctrlClient.List(ctx, &nps, client.MatchingLabels{label.Cluster: cluster.Name})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, np := range nps.Items {
+		var npConditions []condition
+		for _, cond := range np.Status.Conditions {
+			npConditions = append(npConditions, condition{
+				Type:               string(cond.Type),
+				Status:             string(cond.Status),
+				Severity:           string(cond.Severity),
+				Reason:             cond.Reason,
+				LastTransitionTime: cond.LastTransitionTime,
+			})
+		}
+		emit(kindMachinePool, np.Name, npConditions)
+
+		ref := np.Spec.Template.Spec.InfrastructureRef
+		if ref.Kind != kindAzureMachinePool || ref.Name == "" {
+			continue
+		}
+
+		amp := &capzexpv1beta1.AzureMachinePool{}
+		err = c.ctrlClient.Get(ctx, client.ObjectKey{Namespace: np.Namespace, Name: ref.Name}, amp)
+		if err != nil {
+			c.logger.Errorf(ctx, err, "Unable to get AzureMachinePool for np %q in cluster %q", np.Name, cluster.Name)
+			continue
+		}
+		emit(kindAzureMachinePool, amp.Name, fromV1beta1(amp.Status.Conditions))
+	}
+
 	return nil
 }
+
+func fromV1beta1(conditions capiv1beta1.Conditions) []condition {
+	var converted []condition
+	for _, cond := range conditions {
+		converted = append(converted, condition{
+			Type:               string(cond.Type),
+			Status:             string(cond.Status),
+			Severity:           string(cond.Severity),
+			Reason:             cond.Reason,
+			LastTransitionTime: cond.LastTransitionTime,
+		})
+	}
+
+	return converted
+}
@@ -0,0 +1,141 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/label"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	capzv1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capzexpv1beta1 "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1beta1"
+	capiv1alpha4 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	kcpv1beta1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
+	"sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_Conditions_collectConditions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		capiv1beta1.AddToScheme,
+		capzv1beta1.AddToScheme,
+		capzexpv1beta1.AddToScheme,
+		kcpv1beta1.AddToScheme,
+		v1alpha4.AddToScheme,
+	} {
+		err := addToScheme(scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	transition := metav1.Unix(1600000000, 0)
+
+	cluster := &capiv1beta1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "abc12", Namespace: "org-test"},
+		Spec: capiv1beta1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{Kind: kindAzureCluster, Namespace: "org-test", Name: "abc12"},
+			ControlPlaneRef:   &corev1.ObjectReference{Kind: kindKubeadmControlPlane, Namespace: "org-test", Name: "abc12-cp"},
+		},
+		Status: capiv1beta1.ClusterStatus{
+			Conditions: capiv1beta1.Conditions{
+				{Type: capiv1beta1.ReadyCondition, Status: corev1.ConditionTrue, LastTransitionTime: transition},
+			},
+		},
+	}
+	azureCluster := &capzv1beta1.AzureCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "abc12", Namespace: "org-test"},
+		Status: capzv1beta1.AzureClusterStatus{
+			Conditions: capiv1beta1.Conditions{
+				{Type: capiv1beta1.ReadyCondition, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	kcp := &kcpv1beta1.KubeadmControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: "abc12-cp", Namespace: "org-test"},
+		Status: kcpv1beta1.KubeadmControlPlaneStatus{
+			Conditions: capiv1beta1.Conditions{
+				{Type: capiv1beta1.ReadyCondition, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	np := &v1alpha4.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "np001", Namespace: "org-test", Labels: map[string]string{label.Cluster: "abc12"}},
+		Spec: v1alpha4.MachinePoolSpec{
+			Template: capiv1alpha4.MachineTemplateSpec{
+				Spec: capiv1alpha4.MachineSpec{
+					InfrastructureRef: corev1.ObjectReference{Kind: kindAzureMachinePool, Name: "np001"},
+				},
+			},
+		},
+		Status: v1alpha4.MachinePoolStatus{
+			Conditions: capiv1alpha4.Conditions{
+				{Type: capiv1alpha4.ReadyCondition, Status: corev1.ConditionFalse, Severity: capiv1alpha4.ConditionSeverityError, Reason: "ScaleSetProvisionFailed"},
+			},
+		},
+	}
+	amp := &capzexpv1beta1.AzureMachinePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "np001", Namespace: "org-test"},
+		Status: capzexpv1beta1.AzureMachinePoolStatus{
+			Conditions: capiv1beta1.Conditions{
+				{Type: capiv1beta1.ReadyCondition, Status: corev1.ConditionFalse, Severity: capiv1beta1.ConditionSeverityError, Reason: "ScaleSetProvisionFailed"},
+			},
+		},
+	}
+
+	ctrlClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(azureCluster, kcp, np, amp).Build()
+
+	c, err := NewConditions(ctrlClient, microloggertest.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan prometheus.Metric, 100)
+	err = c.collectConditions(context.Background(), cluster, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	conditions := map[string]map[string]string{}
+	var transitions int
+	for m := range ch {
+		metric := &dto.Metric{}
+		err := m.Write(metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		labels := map[string]string{}
+		for _, l := range metric.Label {
+			labels[l.GetName()] = l.GetValue()
+		}
+
+		if m.Desc() == clusterConditionLastTransition {
+			transitions++
+			continue
+		}
+
+		conditions[labels["kind"]+"/"+labels["name"]] = labels
+	}
+
+	if len(conditions) != 5 {
+		t.Fatalf("expected conditions of 5 CRs, got %v", conditions)
+	}
+	if transitions != 1 {
+		t.Fatalf("expected 1 last transition, got %d", transitions)
+	}
+
+	for _, k := range []string{"MachinePool/np001", "AzureMachinePool/np001"} {
+		labels := conditions[k]
+		if labels["type"] != "Ready" || labels["status"] != "False" || labels["severity"] != "Error" || labels["reason"] != "ScaleSetProvisionFailed" {
+			t.Fatalf("unexpected condition labels for %s: %v", k, labels)
+		}
+	}
+}
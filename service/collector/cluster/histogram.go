@@ -0,0 +1,312 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	histogramCreate  = "create"
+	histogramUpgrade = "upgrade"
+
+	histogramsConfigMapKey = "histograms.json"
+
+	// maxObservationAge limits recording to events which finished recently.
+	// Older events may have been recorded before and already been pruned.
+	maxObservationAge = 24 * time.Hour
+	// recordedRetention is how long recorded events are remembered in order to
+	// not record them again. It must exceed maxObservationAge.
+	recordedRetention = 7 * 24 * time.Hour
+)
+
+// durationBuckets are the upper bounds in seconds of the duration histograms,
+// ranging from 5 minutes to 4 hours.
+var durationBuckets = []float64{300, 600, 900, 1200, 1800, 2700, 3600, 5400, 7200, 14400}
+
+type DurationHistogramsConfig struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// ConfigMapName is optional. When empty the histograms are only kept in
+	// memory and reset on restart.
+	ConfigMapName      string
+	ConfigMapNamespace string
+}
+
+// DurationHistograms holds histograms of cluster creation and upgrade
+// durations per release version. They are persisted in a ConfigMap, so that
+// they survive restarts and are shared across replicas.
+type DurationHistograms struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	configMapName      string
+	configMapNamespace string
+
+	mutex sync.Mutex
+	state durationHistogramsState
+}
+
+func NewDurationHistograms(config DurationHistogramsConfig) (*DurationHistograms, error) {
+	if config.ConfigMapName != "" && config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.ConfigMapName != "" && config.ConfigMapNamespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ConfigMapNamespace must not be empty", config)
+	}
+
+	h := &DurationHistograms{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		configMapName:      config.ConfigMapName,
+		configMapNamespace: config.ConfigMapNamespace,
+
+		state: newDurationHistogramsState(),
+	}
+
+	return h, nil
+}
+
+// Observe records the duration of the event identified by id in the histogram
+// of the given name and release version. Every event is only recorded once.
+func (h *DurationHistograms) Observe(ctx context.Context, name, releaseVersion, id string, finished time.Time, d time.Duration) error {
+	now := time.Now()
+	if now.Sub(finished) > maxObservationAge {
+		return nil
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.configMapName == "" {
+		h.state.record(name, releaseVersion, id, finished, d, now)
+		return nil
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, state, err := h.read(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// The ConfigMap is created by the chart, so that the collector does
+		// not need permissions to create ConfigMaps.
+		if cm == nil {
+			return microerror.Maskf(notFoundError, "ConfigMap %s/%s", h.configMapNamespace, h.configMapName)
+		}
+
+		if !state.record(name, releaseVersion, id, finished, d, now) {
+			h.state = state
+			return nil
+		}
+
+		data, err := json.Marshal(state)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[histogramsConfigMapKey] = string(data)
+
+		_, err = h.k8sClient.CoreV1().ConfigMaps(h.configMapNamespace).Update(ctx, cm, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+
+		h.state = state
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// shared returns whether the histograms are shared across replicas through
+// the ConfigMap. Otherwise every replica only holds its own observations.
+func (h *DurationHistograms) shared() bool {
+	return h.configMapName != ""
+}
+
+// Sync loads the histograms recorded by all replicas from the ConfigMap.
+func (h *DurationHistograms) Sync(ctx context.Context) error {
+	if h.configMapName == "" {
+		return nil
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	_, state, err := h.read(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	h.state = state
+
+	return nil
+}
+
+// collect emits the histograms of the given name using desc, which must have
+// the release version as its only label.
+func (h *DurationHistograms) collect(ch chan<- prometheus.Metric, name string, desc *prometheus.Desc) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	histograms := h.state.Histograms[name]
+
+	var releaseVersions []string
+	for v := range histograms {
+		releaseVersions = append(releaseVersions, v)
+	}
+	sort.Strings(releaseVersions)
+
+	for _, v := range releaseVersions {
+		histogram := histograms[v]
+
+		buckets := map[float64]uint64{}
+		for i, upperBound := range durationBuckets {
+			buckets[upperBound] = histogram.Buckets[i]
+		}
+
+		ch <- prometheus.MustNewConstHistogram(
+			desc,
+			histogram.Count,
+			histogram.Sum,
+			buckets,
+			v,
+		)
+	}
+}
+
+// read returns the ConfigMap, which is nil when it does not exist, and the
+// state stored in it.
+func (h *DurationHistograms) read(ctx context.Context) (*corev1.ConfigMap, durationHistogramsState, error) {
+	cm, err := h.k8sClient.CoreV1().ConfigMaps(h.configMapNamespace).Get(ctx, h.configMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, newDurationHistogramsState(), nil
+	} else if err != nil {
+		return nil, durationHistogramsState{}, microerror.Mask(err)
+	}
+
+	state, err := parseDurationHistogramsState(cm.Data[histogramsConfigMapKey])
+	if err != nil {
+		return nil, durationHistogramsState{}, microerror.Mask(err)
+	}
+
+	return cm, state, nil
+}
+
+type durationHistogramsState struct {
+	// Histograms are keyed by histogram name and release version.
+	Histograms map[string]map[string]*durationHistogram `json:"histograms"`
+	// Recorded holds the finish time of the recorded events keyed by their ID.
+	Recorded map[string]time.Time `json:"recorded"`
+}
+
+type durationHistogram struct {
+	Count uint64  `json:"count"`
+	Sum   float64 `json:"sum"`
+	// Buckets are the cumulative counts of the upper bounds in
+	// durationBuckets.
+	Buckets []uint64 `json:"buckets"`
+}
+
+func newDurationHistogramsState() durationHistogramsState {
+	return durationHistogramsState{
+		Histograms: map[string]map[string]*durationHistogram{},
+		Recorded:   map[string]time.Time{},
+	}
+}
+
+func parseDurationHistogramsState(data string) (durationHistogramsState, error) {
+	state := newDurationHistogramsState()
+	if data == "" {
+		return state, nil
+	}
+
+	err := json.Unmarshal([]byte(data), &state)
+	if err != nil {
+		return durationHistogramsState{}, microerror.Mask(err)
+	}
+
+	if state.Histograms == nil {
+		state.Histograms = map[string]map[string]*durationHistogram{}
+	}
+	if state.Recorded == nil {
+		state.Recorded = map[string]time.Time{}
+	}
+
+	// Histograms stored with other buckets cannot be merged and start over.
+	for _, histograms := range state.Histograms {
+		for v, histogram := range histograms {
+			if histogram == nil || len(histogram.Buckets) != len(durationBuckets) {
+				delete(histograms, v)
+			}
+		}
+	}
+
+	return state, nil
+}
+
+// record adds the event to the histogram unless it was recorded before and
+// prunes events which are no longer needed to be remembered. It returns
+// whether the state changed.
+func (s durationHistogramsState) record(name, releaseVersion, id string, finished time.Time, d time.Duration, now time.Time) bool {
+	if _, ok := s.Recorded[id]; ok {
+		return false
+	}
+
+	for k, t := range s.Recorded {
+		if now.Sub(t) > recordedRetention {
+			delete(s.Recorded, k)
+		}
+	}
+	s.Recorded[id] = finished
+
+	histograms, ok := s.Histograms[name]
+	if !ok {
+		histograms = map[string]*durationHistogram{}
+		s.Histograms[name] = histograms
+	}
+
+	histogram, ok := histograms[releaseVersion]
+	if !ok {
+		histogram = &durationHistogram{
+			Buckets: make([]uint64, len(durationBuckets)),
+		}
+		histograms[releaseVersion] = histogram
+	}
+
+	seconds := d.Seconds()
+
+	histogram.Count++
+	histogram.Sum += seconds
+	for i, upperBound := range durationBuckets {
+		if seconds <= upperBound {
+			histogram.Buckets[i]++
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,138 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_durationHistogramsState_record(t *testing.T) {
+	now := time.Now()
+	state := newDurationHistogramsState()
+
+	if !state.record(histogramCreate, "20.0.0", "create/a", now, 10*time.Minute, now) {
+		t.Fatal("expected first event to be recorded")
+	}
+	if state.record(histogramCreate, "20.0.0", "create/a", now, 10*time.Minute, now) {
+		t.Fatal("expected event to be recorded only once")
+	}
+	if !state.record(histogramCreate, "20.0.0", "create/b", now, 50*time.Minute, now) {
+		t.Fatal("expected second event to be recorded")
+	}
+
+	histogram := state.Histograms[histogramCreate]["20.0.0"]
+	if histogram.Count != 2 || histogram.Sum != 3600 {
+		t.Fatalf("unexpected count %d and sum %f", histogram.Count, histogram.Sum)
+	}
+
+	expected := []uint64{0, 1, 1, 1, 1, 1, 2, 2, 2, 2}
+	for i, b := range expected {
+		if histogram.Buckets[i] != b {
+			t.Fatalf("expected buckets %v, got %v", expected, histogram.Buckets)
+		}
+	}
+
+	// Recording an event prunes events outside the retention.
+	state.record(histogramCreate, "20.0.0", "create/c", now, time.Minute, now.Add(recordedRetention+time.Hour))
+	if _, ok := state.Recorded["create/a"]; ok {
+		t.Fatalf("expected expired event to be pruned, got %v", state.Recorded)
+	}
+}
+
+func Test_DurationHistograms_persistence(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "azure-collector-histograms",
+			Namespace: "giantswarm",
+		},
+	})
+
+	newHistograms := func() *DurationHistograms {
+		c := DurationHistogramsConfig{
+			K8sClient: k8sClient,
+			Logger:    microloggertest.New(),
+
+			ConfigMapName:      "azure-collector-histograms",
+			ConfigMapNamespace: "giantswarm",
+		}
+
+		h, err := NewDurationHistograms(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return h
+	}
+
+	finished := time.Now().Add(-time.Minute)
+
+	first := newHistograms()
+	for _, id := range []string{"upgrade/a", "upgrade/b", "upgrade/a"} {
+		err := first.Observe(ctx, histogramUpgrade, "20.0.0", id, finished, 20*time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Events finished too long ago are ignored.
+	err := first.Observe(ctx, histogramUpgrade, "20.0.0", "upgrade/c", finished.Add(-maxObservationAge), 20*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted replica reads the histograms from the ConfigMap.
+	second := newHistograms()
+	err = second.Sync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan prometheus.Metric, 10)
+	second.collect(ch, histogramUpgrade, clusterUpgradeDurationDesc)
+	close(ch)
+
+	var metrics []*dto.Metric
+	for m := range ch {
+		metric := &dto.Metric{}
+		err := m.Write(metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics = append(metrics, metric)
+	}
+
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 histogram, got %d", len(metrics))
+	}
+	if metrics[0].GetHistogram().GetSampleCount() != 2 {
+		t.Fatalf("expected 2 samples, got %d", metrics[0].GetHistogram().GetSampleCount())
+	}
+}
+
+func Test_DurationHistograms_missingConfigMap(t *testing.T) {
+	c := DurationHistogramsConfig{
+		K8sClient: fake.NewSimpleClientset(),
+		Logger:    microloggertest.New(),
+
+		ConfigMapName:      "azure-collector-histograms",
+		ConfigMapNamespace: "giantswarm",
+	}
+
+	h, err := NewDurationHistograms(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = h.Observe(context.Background(), histogramCreate, "20.0.0", "create/a", time.Now(), 20*time.Minute)
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute" //nolint:staticcheck
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	powerStatePrefix = "PowerState/"
+	unknownState     = "unknown"
+)
+
+var (
+	nodePoolVMSSProvisioningState = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "node_pool", "vmss_provisioning_state"),
+		"Provisioning state of the VMSS of a node pool. The value is always 1.",
+		[]string{
+			"cluster_id",
+			"node_pool",
+			"provisioning_state",
+		},
+		nil,
+	)
+	nodePoolInstancesByProvisioningState = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "node_pool", "instances_by_provisioning_state"),
+		"Number of VMSS instances of a node pool by provisioning state.",
+		[]string{
+			"cluster_id",
+			"node_pool",
+			"provisioning_state",
+		},
+		nil,
+	)
+	nodePoolInstancesByPowerState = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "node_pool", "instances_by_power_state"),
+		"Number of VMSS instances of a node pool by power state.",
+		[]string{
+			"cluster_id",
+			"node_pool",
+			"power_state",
+		},
+		nil,
+	)
+	nodePoolInstancesByLatestModel = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "node_pool", "instances_by_latest_model"),
+		"Number of VMSS instances of a node pool by whether they run the latest model of the VMSS.",
+		[]string{
+			"cluster_id",
+			"node_pool",
+			"latest_model",
+		},
+		nil,
+	)
+)
+
+// collectInstances exposes the provisioning state of the VMSS of a node pool
+// and the states of its instances.
+func collectInstances(ch chan<- prometheus.Metric, clusterID, nodePool string, vmss compute.VirtualMachineScaleSet, counts instanceCounts) {
+	provisioningState := unknownState
+	if vmss.VirtualMachineScaleSetProperties != nil && vmss.ProvisioningState != nil {
+		provisioningState = *vmss.ProvisioningState
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		nodePoolVMSSProvisioningState,
+		prometheus.GaugeValue,
+		1,
+		clusterID,
+		nodePool,
+		provisioningState,
+	)
+
+	for _, state := range sortedKeys(counts.provisioningStates) {
+		ch <- prometheus.MustNewConstMetric(
+			nodePoolInstancesByProvisioningState,
+			prometheus.GaugeValue,
+			float64(counts.provisioningStates[state]),
+			clusterID,
+			nodePool,
+			state,
+		)
+	}
+
+	for _, state := range sortedKeys(counts.powerStates) {
+		ch <- prometheus.MustNewConstMetric(
+			nodePoolInstancesByPowerState,
+			prometheus.GaugeValue,
+			float64(counts.powerStates[state]),
+			clusterID,
+			nodePool,
+			state,
+		)
+	}
+
+	for _, latest := range []bool{true, false} {
+		ch <- prometheus.MustNewConstMetric(
+			nodePoolInstancesByLatestModel,
+			prometheus.GaugeValue,
+			float64(counts.latestModel[latest]),
+			clusterID,
+			nodePool,
+			strconv.FormatBool(latest),
+		)
+	}
+}
+
+type instanceCounts struct {
+	provisioningStates map[string]int
+	powerStates        map[string]int
+	latestModel        map[bool]int
+	// zones is keyed by the availability zone of the instances, which is empty
+	// for VMSSs not spanning zones.
+	zones map[string]int
+}
+
+// listVMSSInstances returns the instances of the given VMSS including their
+// instance view.
+func listVMSSInstances(ctx context.Context, vmssVMsClient compute.VirtualMachineScaleSetVMsClient, ref vmssRef) ([]compute.VirtualMachineScaleSetVM, error) {
+	var vms []compute.VirtualMachineScaleSetVM
+
+	r, err := vmssVMsClient.List(ctx, ref.ResourceGroup, ref.Name, "", "", string(compute.InstanceView))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for r.NotDone() {
+		vms = append(vms, r.Values()...)
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return vms, nil
+}
+
+func countInstances(vms []compute.VirtualMachineScaleSetVM) instanceCounts {
+	counts := instanceCounts{
+		provisioningStates: map[string]int{},
+		powerStates:        map[string]int{},
+		latestModel:        map[bool]int{},
+		zones:              map[string]int{},
+	}
+
+	for _, vm := range vms {
+		provisioningState := unknownState
+		powerState := unknownState
+		latestModel := false
+		zone := ""
+
+		if vm.VirtualMachineScaleSetVMProperties != nil {
+			if vm.ProvisioningState != nil {
+				provisioningState = *vm.ProvisioningState
+			}
+			if vm.LatestModelApplied != nil {
+				latestModel = *vm.LatestModelApplied
+			}
+			if vm.InstanceView != nil && vm.InstanceView.Statuses != nil {
+				for _, s := range *vm.InstanceView.Statuses {
+					if s.Code != nil && strings.HasPrefix(*s.Code, powerStatePrefix) {
+						powerState = strings.TrimPrefix(*s.Code, powerStatePrefix)
+					}
+				}
+			}
+		}
+
+		if vm.Zones != nil && len(*vm.Zones) > 0 {
+			zone = (*vm.Zones)[0]
+		}
+
+		counts.provisioningStates[provisioningState]++
+		counts.powerStates[powerState]++
+		counts.latestModel[latestModel]++
+		counts.zones[zone]++
+	}
+
+	return counts
+}
+
+func sortedKeys(m map[string]int) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,51 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func Test_countInstances(t *testing.T) {
+	newVM := func(provisioningState, powerState string, latestModel bool) compute.VirtualMachineScaleSetVM {
+		return compute.VirtualMachineScaleSetVM{
+			VirtualMachineScaleSetVMProperties: &compute.VirtualMachineScaleSetVMProperties{
+				ProvisioningState:  to.StringPtr(provisioningState),
+				LatestModelApplied: to.BoolPtr(latestModel),
+				InstanceView: &compute.VirtualMachineScaleSetVMInstanceView{
+					Statuses: &[]compute.InstanceViewStatus{
+						{Code: to.StringPtr("ProvisioningState/" + provisioningState)},
+						{Code: to.StringPtr("PowerState/" + powerState)},
+					},
+				},
+			},
+		}
+	}
+
+	zonal := newVM("Succeeded", "running", true)
+	zonal.Zones = &[]string{"2"}
+
+	vms := []compute.VirtualMachineScaleSetVM{
+		newVM("Succeeded", "running", true),
+		newVM("Succeeded", "running", false),
+		newVM("Failed", "deallocated", false),
+		{},
+		zonal,
+	}
+
+	counts := countInstances(vms)
+
+	if counts.provisioningStates["Succeeded"] != 3 || counts.provisioningStates["Failed"] != 1 || counts.provisioningStates[unknownState] != 1 {
+		t.Fatalf("unexpected provisioning states %v", counts.provisioningStates)
+	}
+	if counts.powerStates["running"] != 3 || counts.powerStates["deallocated"] != 1 || counts.powerStates[unknownState] != 1 {
+		t.Fatalf("unexpected power states %v", counts.powerStates)
+	}
+	if counts.latestModel[true] != 2 || counts.latestModel[false] != 3 {
+		t.Fatalf("unexpected latest model counts %v", counts.latestModel)
+	}
+	if counts.zones[""] != 4 || counts.zones["2"] != 1 {
+		t.Fatalf("unexpected zones %v", counts.zones)
+	}
+}
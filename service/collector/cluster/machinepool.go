@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	capzexpv1beta1 "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1beta1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// vmssRef identifies the VMSS backing a node pool.
+type vmssRef struct {
+	ResourceGroup string
+	Name          string
+	// VMSize is empty when the AzureMachinePool of the node pool is unknown.
+	VMSize string
+}
+
+// getVMSSRef returns the VMSS backing the given node pool. The VMSS is taken
+// from the provider IDs of the AzureMachinePool, so custom names work as well.
+// Node pools whose AzureMachinePool has no provider ID yet fall back to the
+// naming convention of Giant Swarm clusters.
+func getVMSSRef(ctx context.Context, ctrlClient client.Client, cluster *capiv1beta1.Cluster, np v1alpha4.MachinePool) (vmssRef, error) {
+	ref := vmssRef{
+		ResourceGroup: cluster.Name,
+		Name:          fmt.Sprintf("nodepool-%s", np.Name),
+	}
+
+	infraRef := np.Spec.Template.Spec.InfrastructureRef
+	if infraRef.Name == "" {
+		return ref, nil
+	}
+
+	amp := &capzexpv1beta1.AzureMachinePool{}
+	err := ctrlClient.Get(ctx, client.ObjectKey{Namespace: np.Namespace, Name: infraRef.Name}, amp)
+	if err != nil {
+		return vmssRef{}, microerror.Mask(err)
+	}
+
+	ref.VMSize = amp.Spec.Template.VMSize
+
+	providerIDs := append([]string{amp.Spec.ProviderID}, amp.Spec.ProviderIDList...)
+	for _, id := range providerIDs {
+		resourceGroup, name, ok := parseVMSSProviderID(id)
+		if ok {
+			ref.ResourceGroup = resourceGroup
+			ref.Name = name
+			break
+		}
+	}
+
+	return ref, nil
+}
+
+// parseVMSSProviderID returns the resource group and name of the VMSS in a
+// provider ID of either the VMSS or one of its instances, e.g.
+// "azure:///subscriptions/s/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/vmss/virtualMachines/0".
+func parseVMSSProviderID(providerID string) (string, string, bool) {
+	parts := strings.Split(strings.TrimPrefix(providerID, "azure://"), "/")
+
+	var resourceGroup, name string
+	for i := 0; i+1 < len(parts); i++ {
+		switch {
+		case strings.EqualFold(parts[i], "resourceGroups"):
+			resourceGroup = parts[i+1]
+		case strings.EqualFold(parts[i], "virtualMachineScaleSets"):
+			name = parts[i+1]
+		}
+	}
+
+	if resourceGroup == "" || name == "" {
+		return "", "", false
+	}
+
+	return resourceGroup, name, true
+}
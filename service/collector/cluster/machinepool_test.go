@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_parseVMSSProviderID(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		providerID            string
+		expectedResourceGroup string
+		expectedName          string
+		expectedOK            bool
+	}{
+		{
+			name:                  "case 0: VMSS provider ID",
+			providerID:            "azure:///subscriptions/sub/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss",
+			expectedResourceGroup: "my-rg",
+			expectedName:          "my-vmss",
+			expectedOK:            true,
+		},
+		{
+			name:                  "case 1: VMSS instance provider ID",
+			providerID:            "azure:///subscriptions/sub/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss/virtualMachines/3",
+			expectedResourceGroup: "my-rg",
+			expectedName:          "my-vmss",
+			expectedOK:            true,
+		},
+		{
+			name:                  "case 2: resource group in lower case",
+			providerID:            "azure:///subscriptions/sub/resourcegroups/my-rg/providers/Microsoft.Compute/virtualMachineScaleSets/my-vmss",
+			expectedResourceGroup: "my-rg",
+			expectedName:          "my-vmss",
+			expectedOK:            true,
+		},
+		{
+			name:       "case 3: virtual machine provider ID",
+			providerID: "azure:///subscriptions/sub/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm",
+		},
+		{
+			name:       "case 4: empty provider ID",
+			providerID: "",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			resourceGroup, name, ok := parseVMSSProviderID(tc.providerID)
+			if ok != tc.expectedOK {
+				t.Fatalf("expected ok %t, got %t", tc.expectedOK, ok)
+			}
+			if resourceGroup != tc.expectedResourceGroup {
+				t.Fatalf("expected resource group %q, got %q", tc.expectedResourceGroup, resourceGroup)
+			}
+			if name != tc.expectedName {
+				t.Fatalf("expected name %q, got %q", tc.expectedName, name)
+			}
+		})
+	}
+}
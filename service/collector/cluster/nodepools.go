@@ -2,10 +2,12 @@ package cluster
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute" //nolint:staticcheck
 	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest/to"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/apiextensions/v6/pkg/label"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
@@ -40,6 +42,54 @@ var (
 		},
 		nil,
 	)
+
+	nodePoolWorkers = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "node_pool", "worker_nodes"),
+		"Exposes the number of worker nodes in a node pool by VM size and availability zone",
+		[]string{
+			"cluster_id",
+			"node_pool",
+			"vm_size",
+			"zone",
+		},
+		nil,
+	)
+
+	nodePoolReplicas = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "node_pool", "replicas"),
+		"Exposes the replicas of a node pool as desired and seen by the MachinePool and as the capacity of its VMSS",
+		[]string{
+			"cluster_id",
+			"node_pool",
+			"type",
+		},
+		nil,
+	)
+
+	nodePoolReplicasMismatch = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "node_pool", "replicas_mismatch"),
+		"Exposes whether the desired or current replicas of the MachinePool disagree with the capacity of its VMSS",
+		[]string{
+			"cluster_id",
+			"node_pool",
+		},
+		nil,
+	)
+)
+
+const (
+	controlPlaneNodeRoleLabel = "node-role.kubernetes.io/control-plane"
+	kubernetesRoleLabel       = "kubernetes.io/role"
+	masterNodeRoleLabel       = "node-role.kubernetes.io/master"
+	vintageRoleLabel          = "role"
+	vintageRoleMaster         = "master"
+)
+
+const (
+	replicasDesired      = "desired"
+	replicasCurrent      = "current"
+	replicasReady        = "ready"
+	replicasVMSSCapacity = "vmss_capacity"
 )
 
 func NewNodePools(ctrlClient client.Client, logger micrologger.Logger) (*NodePools, error) {
@@ -70,35 +120,71 @@ func (n *NodePools) Collect(ctx context.Context, cluster *capiv1beta1.Cluster, c
 
 		nodePoolsCount = len(nps.Items)
 
+		var vmssClient compute.VirtualMachineScaleSetsClient
+		var vmssVMsClient compute.VirtualMachineScaleSetVMsClient
+		var clientsOK bool
+		if nodePoolsCount > 0 {
+			vmssClient, vmssVMsClient, clientsOK = n.getClients(ctx, cluster)
+		}
+
 		for _, np := range nps.Items {
-			// Get VMSS regarding to this NP and get current size.
-			azureCredentials, err := capzcredentials.GetAzureCredentialsFromMetadata(ctx, n.ctrlClient, cluster.ObjectMeta)
-			if err != nil {
-				n.logger.Errorf(ctx, err, "Unable to get azure credentials for cluster %q", cluster.Name)
+			// The replicas of the MachinePool are exposed even when its VMSS
+			// cannot be found, since that is a disagreement to alert on.
+			if !clientsOK {
+				n.collectReplicas(ch, cluster.Name, np, nil, false)
 				continue
 			}
 
-			var vmssClient compute.VirtualMachineScaleSetsClient
-			{
-				settings := auth.NewClientCredentialsConfig(azureCredentials.ClientID, azureCredentials.ClientSecret, azureCredentials.TenantID)
-				authorizer, err := settings.Authorizer()
-				if err != nil {
-					n.logger.Errorf(ctx, err, "Unable to use azure credentials for cluster %q", cluster.Name)
-					continue
-				}
-				vmssClient = compute.NewVirtualMachineScaleSetsClient(azureCredentials.SubscriptionID)
-				vmssClient.Client.Authorizer = authorizer
+			// Get VMSS regarding to this NP and get current size.
+			ref, err := getVMSSRef(ctx, n.ctrlClient, cluster, np)
+			if err != nil {
+				n.logger.Errorf(ctx, err, "Unable to get AzureMachinePool for np %q in cluster %q", np.Name, cluster.Name)
+				n.collectReplicas(ch, cluster.Name, np, nil, false)
+				continue
 			}
 
-			resp, err := vmssClient.Get(ctx, cluster.Name, fmt.Sprintf("nodepool-%s", np.Name))
+			resp, err := vmssClient.Get(ctx, ref.ResourceGroup, ref.Name)
 			if err != nil {
+				missing := resp.Response.Response != nil && resp.StatusCode == http.StatusNotFound
 				n.logger.Errorf(ctx, err, "Unable to get vmss for np %q in cluster %q", np.Name, cluster.Name)
+				n.collectReplicas(ch, cluster.Name, np, nil, missing)
 				continue
 			}
 
 			if resp.Sku != nil && resp.Sku.Capacity != nil {
 				currentWorkersCount += *resp.Sku.Capacity
 			}
+
+			n.collectReplicas(ch, cluster.Name, np, resp.Sku, false)
+
+			vmSize := ref.VMSize
+			if resp.Sku != nil && resp.Sku.Name != nil {
+				vmSize = *resp.Sku.Name
+			}
+
+			// The instances are listed once per node pool and feed both the
+			// worker nodes by zone and the instance states.
+			vms, err := listVMSSInstances(ctx, vmssVMsClient, ref)
+			if err != nil {
+				n.logger.Errorf(ctx, err, "Unable to list vmss instances for np %q in cluster %q", np.Name, cluster.Name)
+				continue
+			}
+
+			counts := countInstances(vms)
+			collectInstances(ch, cluster.Name, np.Name, resp, counts)
+
+			zones := counts.zones
+			for _, zone := range sortedKeys(zones) {
+				ch <- prometheus.MustNewConstMetric(
+					nodePoolWorkers,
+					prometheus.GaugeValue,
+					float64(zones[zone]),
+					cluster.Name,
+					np.Name,
+					vmSize,
+					zone,
+				)
+			}
 		}
 	}
 
@@ -119,8 +205,150 @@ func (n *NodePools) Collect(ctx context.Context, cluster *capiv1beta1.Cluster, c
 	return nil
 }
 
+func (n *NodePools) getClients(ctx context.Context, cluster *capiv1beta1.Cluster) (compute.VirtualMachineScaleSetsClient, compute.VirtualMachineScaleSetVMsClient, bool) {
+	var vmssClient compute.VirtualMachineScaleSetsClient
+	var vmssVMsClient compute.VirtualMachineScaleSetVMsClient
+
+	azureCredentials, err := capzcredentials.GetAzureCredentialsFromMetadata(ctx, n.ctrlClient, cluster.ObjectMeta)
+	if err != nil {
+		n.logger.Errorf(ctx, err, "Unable to get azure credentials for cluster %q", cluster.Name)
+		return vmssClient, vmssVMsClient, false
+	}
+
+	settings := auth.NewClientCredentialsConfig(azureCredentials.ClientID, azureCredentials.ClientSecret, azureCredentials.TenantID)
+	authorizer, err := settings.Authorizer()
+	if err != nil {
+		n.logger.Errorf(ctx, err, "Unable to use azure credentials for cluster %q", cluster.Name)
+		return vmssClient, vmssVMsClient, false
+	}
+
+	vmssClient = compute.NewVirtualMachineScaleSetsClient(azureCredentials.SubscriptionID)
+	vmssClient.Client.Authorizer = authorizer
+	vmssVMsClient = compute.NewVirtualMachineScaleSetVMsClient(azureCredentials.SubscriptionID)
+	vmssVMsClient.Client.Authorizer = authorizer
+
+	return vmssClient, vmssVMsClient, true
+}
+
+// CollectVintage exposes the worker nodes of a vintage cluster as recorded in
+// the AzureConfig status.
+func (n *NodePools) CollectVintage(ctx context.Context, cr *providerv1alpha1.AzureConfig, ch chan<- prometheus.Metric) error {
+	ch <- prometheus.MustNewConstMetric(
+		clusterWorkers,
+		prometheus.GaugeValue,
+		float64(countVintageWorkers(cr.Status.Cluster.Nodes)),
+		cr.Name,
+	)
+
+	return nil
+}
+
 func (n *NodePools) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- clusterNodePools
 	ch <- clusterWorkers
+	ch <- nodePoolWorkers
+	ch <- nodePoolReplicas
+	ch <- nodePoolReplicasMismatch
+	ch <- nodePoolVMSSProvisioningState
+	ch <- nodePoolInstancesByProvisioningState
+	ch <- nodePoolInstancesByPowerState
+	ch <- nodePoolInstancesByLatestModel
 	return nil
 }
+
+// collectReplicas exposes the replicas of the MachinePool next to the capacity
+// of its VMSS, so that CAPI and Azure disagreeing can be alerted on. A missing
+// VMSS is always a mismatch.
+func (n *NodePools) collectReplicas(ch chan<- prometheus.Metric, clusterID string, np v1alpha4.MachinePool, sku *compute.Sku, vmssMissing bool) {
+	replicas := map[string]*int64{
+		replicasCurrent: to.Int64Ptr(int64(np.Status.Replicas)),
+		replicasReady:   to.Int64Ptr(int64(np.Status.ReadyReplicas)),
+	}
+	if np.Spec.Replicas != nil {
+		replicas[replicasDesired] = to.Int64Ptr(int64(*np.Spec.Replicas))
+	}
+	if sku != nil && sku.Capacity != nil {
+		replicas[replicasVMSSCapacity] = sku.Capacity
+	}
+
+	for _, t := range []string{replicasDesired, replicasCurrent, replicasReady, replicasVMSSCapacity} {
+		v, ok := replicas[t]
+		if !ok {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			nodePoolReplicas,
+			prometheus.GaugeValue,
+			float64(*v),
+			clusterID,
+			np.Name,
+			t,
+		)
+	}
+
+	var mismatch float64
+	if vmssMissing || replicasMismatch(replicas) {
+		mismatch = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		nodePoolReplicasMismatch,
+		prometheus.GaugeValue,
+		mismatch,
+		clusterID,
+		np.Name,
+	)
+}
+
+// replicasMismatch returns whether the desired or current replicas of the
+// MachinePool differ from the capacity of the VMSS. Missing values are not
+// considered a mismatch.
+func replicasMismatch(replicas map[string]*int64) bool {
+	capacity, ok := replicas[replicasVMSSCapacity]
+	if !ok {
+		return false
+	}
+
+	for _, t := range []string{replicasDesired, replicasCurrent} {
+		v, ok := replicas[t]
+		if ok && *v != *capacity {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (n *NodePools) Name() string {
+	return "node_pools"
+}
+
+// countVintageWorkers returns the number of nodes in the AzureConfig status
+// which are not masters.
+func countVintageWorkers(nodes []providerv1alpha1.StatusClusterNode) int {
+	var count int
+	for _, node := range nodes {
+		if isMasterNode(node.Labels) {
+			continue
+		}
+
+		count++
+	}
+
+	return count
+}
+
+func isMasterNode(labels map[string]string) bool {
+	if labels[vintageRoleLabel] == vintageRoleMaster || labels[kubernetesRoleLabel] == vintageRoleMaster {
+		return true
+	}
+
+	for _, l := range []string{masterNodeRoleLabel, controlPlaneNodeRoleLabel} {
+		if _, ok := labels[l]; ok {
+			return true
+		}
+	}
+
+	return false
+}
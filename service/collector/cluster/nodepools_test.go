@@ -0,0 +1,171 @@
+package cluster
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/to"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+)
+
+func Test_replicasMismatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		replicas map[string]*int64
+		expected bool
+	}{
+		{
+			name: "case 0: all agree",
+			replicas: map[string]*int64{
+				replicasDesired:      to.Int64Ptr(3),
+				replicasCurrent:      to.Int64Ptr(3),
+				replicasReady:        to.Int64Ptr(2),
+				replicasVMSSCapacity: to.Int64Ptr(3),
+			},
+			expected: false,
+		},
+		{
+			name: "case 1: desired differs from vmss capacity",
+			replicas: map[string]*int64{
+				replicasDesired:      to.Int64Ptr(5),
+				replicasCurrent:      to.Int64Ptr(3),
+				replicasVMSSCapacity: to.Int64Ptr(3),
+			},
+			expected: true,
+		},
+		{
+			name: "case 2: current differs from vmss capacity",
+			replicas: map[string]*int64{
+				replicasCurrent:      to.Int64Ptr(2),
+				replicasVMSSCapacity: to.Int64Ptr(3),
+			},
+			expected: true,
+		},
+		{
+			name: "case 3: vmss capacity unknown",
+			replicas: map[string]*int64{
+				replicasDesired: to.Int64Ptr(5),
+				replicasCurrent: to.Int64Ptr(3),
+			},
+			expected: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			mismatch := replicasMismatch(tc.replicas)
+			if mismatch != tc.expected {
+				t.Fatalf("expected mismatch %t got %t", tc.expected, mismatch)
+			}
+		})
+	}
+}
+
+func Test_countVintageWorkers(t *testing.T) {
+	nodes := []providerv1alpha1.StatusClusterNode{
+		{Name: "master-0", Labels: map[string]string{"role": "master"}},
+		{Name: "master-1", Labels: map[string]string{"node-role.kubernetes.io/control-plane": ""}},
+		{Name: "worker-0", Labels: map[string]string{"role": "worker"}},
+		{Name: "worker-1", Labels: map[string]string{"kubernetes.io/role": "worker"}},
+		{Name: "worker-2"},
+	}
+
+	count := countVintageWorkers(nodes)
+	if count != 3 {
+		t.Fatalf("expected 3 workers, got %d", count)
+	}
+}
+
+func Test_NodePools_collectReplicas(t *testing.T) {
+	np := v1alpha4.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "np001"},
+		Spec:       v1alpha4.MachinePoolSpec{Replicas: to.Int32Ptr(3)},
+		Status:     v1alpha4.MachinePoolStatus{Replicas: 3, ReadyReplicas: 2},
+	}
+
+	testCases := []struct {
+		name             string
+		sku              *compute.Sku
+		vmssMissing      bool
+		expectedReplicas map[string]float64
+		expectedMismatch float64
+	}{
+		{
+			name: "case 0: vmss capacity agrees with the MachinePool",
+			sku:  &compute.Sku{Capacity: to.Int64Ptr(3)},
+			expectedReplicas: map[string]float64{
+				replicasDesired:      3,
+				replicasCurrent:      3,
+				replicasReady:        2,
+				replicasVMSSCapacity: 3,
+			},
+			expectedMismatch: 0,
+		},
+		{
+			name:        "case 1: missing vmss is a mismatch",
+			vmssMissing: true,
+			expectedReplicas: map[string]float64{
+				replicasDesired: 3,
+				replicasCurrent: 3,
+				replicasReady:   2,
+			},
+			expectedMismatch: 1,
+		},
+		{
+			name: "case 2: unknown vmss still exposes the MachinePool replicas",
+			expectedReplicas: map[string]float64{
+				replicasDesired: 3,
+				replicasCurrent: 3,
+				replicasReady:   2,
+			},
+			expectedMismatch: 0,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			ch := make(chan prometheus.Metric, 10)
+			n := &NodePools{}
+			n.collectReplicas(ch, "abc12", np, tc.sku, tc.vmssMissing)
+			close(ch)
+
+			replicas := map[string]float64{}
+			var mismatch *float64
+			for m := range ch {
+				var metric dto.Metric
+				err := m.Write(&metric)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				switch m.Desc() {
+				case nodePoolReplicas:
+					for _, l := range metric.GetLabel() {
+						if l.GetName() == "type" {
+							replicas[l.GetValue()] = metric.GetGauge().GetValue()
+						}
+					}
+				case nodePoolReplicasMismatch:
+					mismatch = to.Float64Ptr(metric.GetGauge().GetValue())
+				}
+			}
+
+			if !cmp.Equal(replicas, tc.expectedReplicas) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedReplicas, replicas))
+			}
+			if mismatch == nil || *mismatch != tc.expectedMismatch {
+				t.Fatalf("expected mismatch %v got %v", tc.expectedMismatch, mismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package cluster
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/compute/mgmt/compute"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/giantswarm/apiextensions/v6/pkg/annotation"
+	"github.com/giantswarm/apiextensions/v6/pkg/label"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	capzexpv1beta1 "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1beta1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/azure-collector/v3/internal/capzcredentials"
+	"github.com/giantswarm/azure-collector/v3/service/collector/sku"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+)
+
+const (
+	// nodeGroupMaxSizeAnnotation is the annotation used by the cluster-autoscaler
+	// CAPI provider, next to the legacy one in annotation.NodePoolMaxSize.
+	nodeGroupMaxSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-max-size"
+
+	// regionalCoresQuota is the name of the quota limiting the vCPUs of all
+	// families in a location.
+	regionalCoresQuota = "cores"
+)
+
+var (
+	quotaHeadroomNodes = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "cluster", "quota_headroom_nodes"),
+		"Number of nodes the vCPU quota allows to add to a node pool beyond the nodes needed to reach its maximum size. Negative values mean scaling to the maximum size will fail on quota.",
+		[]string{
+			"cluster_id",
+			"node_pool",
+			"vm_size",
+			"location",
+		},
+		nil,
+	)
+)
+
+type QuotaHeadroom struct {
+	ctrlClient client.Client
+	logger     micrologger.Logger
+	skuCache   *sku.Cache
+}
+
+func NewQuotaHeadroom(ctrlClient client.Client, logger micrologger.Logger, skuCache *sku.Cache) (*QuotaHeadroom, error) {
+	if ctrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "ctrlClient must not be empty")
+	}
+	if logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "logger must not be empty")
+	}
+	if skuCache == nil {
+		return nil, microerror.Maskf(invalidConfigError, "skuCache must not be empty")
+	}
+
+	c := &QuotaHeadroom{
+		ctrlClient: ctrlClient,
+		logger:     logger,
+		skuCache:   skuCache,
+	}
+
+	return c, nil
+}
+
+func (q *QuotaHeadroom) Collect(ctx context.Context, cluster *capiv1beta1.Cluster, ch chan<- prometheus.Metric) error {
+	nps := v1alpha4.MachinePoolList{}
+	err := q.ctrlClient.List(ctx, &nps, client.MatchingLabels{label.Cluster: cluster.Name})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(nps.Items) == 0 {
+		return nil
+	}
+
+	azureCredentials, err := capzcredentials.GetAzureCredentialsFromMetadata(ctx, q.ctrlClient, cluster.ObjectMeta)
+	if err != nil {
+		q.logger.Errorf(ctx, err, "Unable to get azure credentials for cluster %q", cluster.Name)
+		return nil
+	}
+
+	var usageClient compute.UsageClient
+	var skusClient compute.ResourceSkusClient
+	{
+		settings := auth.NewClientCredentialsConfig(azureCredentials.ClientID, azureCredentials.ClientSecret, azureCredentials.TenantID)
+		authorizer, err := settings.Authorizer()
+		if err != nil {
+			q.logger.Errorf(ctx, err, "Unable to use azure credentials for cluster %q", cluster.Name)
+			return nil
+		}
+
+		usageClient = compute.NewUsageClient(azureCredentials.SubscriptionID)
+		usageClient.Client.Authorizer = authorizer
+		skusClient = compute.NewResourceSkusClient(azureCredentials.SubscriptionID)
+		skusClient.Client.Authorizer = authorizer
+	}
+
+	// Quotas are per location, so they are only fetched once for all node
+	// pools in the same location. SKUs are cached across collections.
+	remainingByLocation := map[string]map[string]int64{}
+
+	for _, np := range nps.Items {
+		if np.Spec.Template.Spec.InfrastructureRef.Name == "" {
+			continue
+		}
+
+		amp := &capzexpv1beta1.AzureMachinePool{}
+		err = q.ctrlClient.Get(ctx, client.ObjectKey{Namespace: np.Namespace, Name: np.Spec.Template.Spec.InfrastructureRef.Name}, amp)
+		if err != nil {
+			q.logger.Errorf(ctx, err, "Unable to get AzureMachinePool for np %q in cluster %q", np.Name, cluster.Name)
+			continue
+		}
+
+		location := inventory.NormalizeLocation(amp.Spec.Location)
+		vmSize := amp.Spec.Template.VMSize
+
+		remaining, ok := remainingByLocation[location]
+		if !ok {
+			remaining, err = q.remainingQuota(ctx, usageClient, location)
+			if err != nil {
+				q.logger.Errorf(ctx, err, "Unable to get compute usages in location %q for cluster %q", location, cluster.Name)
+				continue
+			}
+			remainingByLocation[location] = remaining
+		}
+
+		skus, err := q.skuCache.ListVMs(ctx, skusClient, location)
+		if err != nil {
+			q.logger.Errorf(ctx, err, "Unable to get VM SKUs in location %q for cluster %q", location, cluster.Name)
+			continue
+		}
+
+		vm, ok := skus[strings.ToLower(vmSize)]
+		if !ok || vm.VCPUs <= 0 {
+			q.logger.Debugf(ctx, "VM size %q of np %q in cluster %q not found in location %q", vmSize, np.Name, cluster.Name, location)
+			continue
+		}
+
+		available, ok := availableVCPUs(remaining, vm.Family)
+		if !ok {
+			q.logger.Debugf(ctx, "No vCPU quota found for family %q of np %q in cluster %q", vm.Family, np.Name, cluster.Name)
+			continue
+		}
+
+		headroom := quotaHeadroom(available, vm.VCPUs, int64(np.Status.Replicas), maxReplicas(np))
+
+		ch <- prometheus.MustNewConstMetric(
+			quotaHeadroomNodes,
+			prometheus.GaugeValue,
+			float64(headroom),
+			cluster.Name,
+			np.Name,
+			vmSize,
+			location,
+		)
+	}
+
+	return nil
+}
+
+func (q *QuotaHeadroom) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- quotaHeadroomNodes
+	return nil
+}
+
+func (q *QuotaHeadroom) Name() string {
+	return "quota_headroom"
+}
+
+// remainingQuota returns the remaining vCPUs of every compute quota in the
+// given location keyed by the quota name, e.g. "standardDSv3Family".
+func (q *QuotaHeadroom) remainingQuota(ctx context.Context, usageClient compute.UsageClient, location string) (map[string]int64, error) {
+	remaining := map[string]int64{}
+
+	r, err := usageClient.List(ctx, location)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for r.NotDone() {
+		for _, v := range r.Values() {
+			if v.Name == nil || v.Name.Value == nil || v.CurrentValue == nil || v.Limit == nil {
+				continue
+			}
+
+			remaining[strings.ToLower(*v.Name.Value)] = *v.Limit - int64(*v.CurrentValue)
+		}
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return remaining, nil
+}
+
+// maxReplicas returns the maximum size of the node pool as configured for the
+// cluster-autoscaler, falling back to the desired replicas when the node pool
+// is not autoscaled.
+func maxReplicas(np v1alpha4.MachinePool) int64 {
+	for _, a := range []string{nodeGroupMaxSizeAnnotation, annotation.NodePoolMaxSize} {
+		v, ok := np.Annotations[a]
+		if !ok {
+			continue
+		}
+
+		max, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			return max
+		}
+	}
+
+	if np.Spec.Replicas != nil {
+		return int64(*np.Spec.Replicas)
+	}
+
+	return int64(np.Status.Replicas)
+}
+
+// availableVCPUs returns the vCPUs which can still be allocated for the given
+// family, limited by both the family and the regional quota.
+func availableVCPUs(remaining map[string]int64, family string) (int64, bool) {
+	familyRemaining, familyOK := remaining[family]
+	regionalRemaining, regionalOK := remaining[regionalCoresQuota]
+
+	switch {
+	case familyOK && regionalOK && regionalRemaining < familyRemaining:
+		return regionalRemaining, true
+	case familyOK:
+		return familyRemaining, true
+	case regionalOK:
+		return regionalRemaining, true
+	}
+
+	return 0, false
+}
+
+// quotaHeadroom returns how many nodes with the given number of vCPUs fit into
+// the available vCPUs beyond the nodes needed to scale from current to max
+// nodes.
+func quotaHeadroom(available, vCPUs, current, max int64) int64 {
+	needed := max - current
+	if needed < 0 {
+		needed = 0
+	}
+
+	fitting := available / vCPUs
+	if available < 0 && available%vCPUs != 0 {
+		fitting--
+	}
+
+	return fitting - needed
+}
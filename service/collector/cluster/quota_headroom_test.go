@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_quotaHeadroom(t *testing.T) {
+	testCases := []struct {
+		name             string
+		remaining        map[string]int64
+		family           string
+		vCPUs            int64
+		current          int64
+		max              int64
+		expectedHeadroom int64
+		expectedOK       bool
+	}{
+		{
+			name:             "case 0: family quota allows scaling beyond max",
+			remaining:        map[string]int64{"standarddsv3family": 40, "cores": 100},
+			family:           "standarddsv3family",
+			vCPUs:            4,
+			current:          3,
+			max:              5,
+			expectedHeadroom: 8,
+			expectedOK:       true,
+		},
+		{
+			name:             "case 1: regional quota is the limit",
+			remaining:        map[string]int64{"standarddsv3family": 40, "cores": 8},
+			family:           "standarddsv3family",
+			vCPUs:            4,
+			current:          3,
+			max:              5,
+			expectedHeadroom: 0,
+			expectedOK:       true,
+		},
+		{
+			name:             "case 2: scaling to max fails on quota",
+			remaining:        map[string]int64{"standarddsv3family": 6},
+			family:           "standarddsv3family",
+			vCPUs:            4,
+			current:          1,
+			max:              10,
+			expectedHeadroom: -8,
+			expectedOK:       true,
+		},
+		{
+			name:             "case 3: quota already exceeded",
+			remaining:        map[string]int64{"standarddsv3family": -2},
+			family:           "standarddsv3family",
+			vCPUs:            4,
+			current:          5,
+			max:              5,
+			expectedHeadroom: -1,
+			expectedOK:       true,
+		},
+		{
+			name:       "case 4: no quota known",
+			remaining:  map[string]int64{},
+			family:     "standarddsv3family",
+			vCPUs:      4,
+			expectedOK: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			available, ok := availableVCPUs(tc.remaining, tc.family)
+			if ok != tc.expectedOK {
+				t.Fatalf("expected ok %t got %t", tc.expectedOK, ok)
+			}
+			if !ok {
+				return
+			}
+
+			headroom := quotaHeadroom(available, tc.vCPUs, tc.current, tc.max)
+			if headroom != tc.expectedHeadroom {
+				t.Fatalf("expected headroom %d got %d", tc.expectedHeadroom, headroom)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package cluster
 import (
 	"context"
 
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/apiextensions/v6/pkg/label"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
@@ -66,3 +67,39 @@ func (c *Releases) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- clusterRelease
 	return nil
 }
+
+func (c *Releases) Name() string {
+	return "releases"
+}
+
+func (c *Releases) CollectVintage(ctx context.Context, cr *providerv1alpha1.AzureConfig, ch chan<- prometheus.Metric) error {
+	releaseVersion, ok := vintageReleaseVersion(cr)
+	if !ok {
+		c.logger.Debugf(ctx, "AzureConfig %#q has no %#q label nor version in its status. Skipping", cr.Name, label.ReleaseVersion)
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		clusterRelease,
+		prometheus.GaugeValue,
+		1,
+		cr.Name,
+		releaseVersion,
+	)
+
+	return nil
+}
+
+// vintageReleaseVersion returns the release version of a vintage cluster from
+// the release label of its AzureConfig, falling back to the latest version
+// recorded in its status.
+func vintageReleaseVersion(cr *providerv1alpha1.AzureConfig) (string, bool) {
+	releaseVersion, ok := cr.Labels[label.ReleaseVersion]
+	if ok && releaseVersion != "" {
+		return releaseVersion, true
+	}
+
+	releaseVersion = cr.Status.Cluster.LatestVersion()
+
+	return releaseVersion, releaseVersion != ""
+}
@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/apiextensions/v6/pkg/label"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_vintageReleaseVersion(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name            string
+		labels          map[string]string
+		versions        []providerv1alpha1.StatusClusterVersion
+		expectedVersion string
+		expectedOK      bool
+	}{
+		{
+			name:            "case 0: release label",
+			labels:          map[string]string{label.ReleaseVersion: "16.1.0"},
+			versions:        []providerv1alpha1.StatusClusterVersion{{Semver: "16.0.0", LastTransitionTime: metav1.NewTime(now)}},
+			expectedVersion: "16.1.0",
+			expectedOK:      true,
+		},
+		{
+			name: "case 1: latest version from status",
+			versions: []providerv1alpha1.StatusClusterVersion{
+				{Semver: "15.0.0", LastTransitionTime: metav1.NewTime(now.Add(-time.Hour))},
+				{Semver: "16.0.0", LastTransitionTime: metav1.NewTime(now)},
+			},
+			expectedVersion: "16.0.0",
+			expectedOK:      true,
+		},
+		{
+			name:       "case 2: no version",
+			expectedOK: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			cr := &providerv1alpha1.AzureConfig{
+				ObjectMeta: metav1.ObjectMeta{Labels: tc.labels},
+			}
+			cr.Status.Cluster.Versions = tc.versions
+
+			version, ok := vintageReleaseVersion(cr)
+			if ok != tc.expectedOK {
+				t.Fatalf("expected ok %t, got %t", tc.expectedOK, ok)
+			}
+			if version != tc.expectedVersion {
+				t.Fatalf("expected version %q, got %q", tc.expectedVersion, version)
+			}
+		})
+	}
+}
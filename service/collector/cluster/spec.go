@@ -3,6 +3,7 @@ package cluster
 import (
 	"context"
 
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	"github.com/prometheus/client_golang/prometheus"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -10,4 +11,24 @@ import (
 type ClusterCollector interface {
 	Collect(ctx context.Context, cr *capiv1beta1.Cluster, ch chan<- prometheus.Metric) error
 	Describe(ch chan<- *prometheus.Desc) error
+	// Name identifies the collector in the inventory.
+	Name() string
+}
+
+// VintageClusterCollector is implemented by cluster collectors which also
+// expose their metrics for vintage clusters backed by an AzureConfig CR. The
+// metrics are described by the ClusterCollector.
+type VintageClusterCollector interface {
+	CollectVintage(ctx context.Context, cr *providerv1alpha1.AzureConfig, ch chan<- prometheus.Metric) error
+	// Name identifies the collector in the inventory.
+	Name() string
+}
+
+// AggregateCollector is implemented by cluster collectors which expose metrics
+// aggregated over all clusters. CollectAggregate is called on every replica
+// once the clusters it owns have been collected. Only the replica owning the
+// aggregates is told to expose them, so that they are not multiplied by the
+// number of shards.
+type AggregateCollector interface {
+	CollectAggregate(ctx context.Context, expose bool, ch chan<- prometheus.Metric) error
 }
@@ -2,7 +2,10 @@ package cluster
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	aeconditions "github.com/giantswarm/apiextensions/v6/pkg/conditions"
 	"github.com/giantswarm/apiextensions/v6/pkg/label"
 	"github.com/giantswarm/microerror"
@@ -15,7 +18,10 @@ import (
 
 type TransitionTime struct {
 	ctrlClient client.Client
+	histograms *DurationHistograms
 	logger     micrologger.Logger
+
+	upgrades *upgradeTracker
 }
 
 var (
@@ -28,19 +34,65 @@ var (
 		},
 		nil,
 	)
+
+	clusterTransitionUpgradeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "cluster", "upgrade_transition"),
+		"Duration in seconds of the latest completed cluster upgrade.",
+		[]string{
+			"cluster_id",
+			"from_release_version",
+			"to_release_version",
+		},
+		nil,
+	)
+
+	clusterUpgradingDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "cluster", "upgrading_seconds"),
+		"Time in seconds a cluster has been upgrading for.",
+		[]string{
+			"cluster_id",
+			"from_release_version",
+			"to_release_version",
+		},
+		nil,
+	)
+
+	clusterCreateDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "cluster", "create_duration_seconds"),
+		"Histogram of cluster creation durations.",
+		[]string{
+			"release_version",
+		},
+		nil,
+	)
+
+	clusterUpgradeDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "cluster", "upgrade_duration_seconds"),
+		"Histogram of cluster upgrade durations by the release version upgraded to.",
+		[]string{
+			"release_version",
+		},
+		nil,
+	)
 )
 
-func NewTransitionTime(ctrlClient client.Client, logger micrologger.Logger) (*TransitionTime, error) {
+func NewTransitionTime(ctrlClient client.Client, histograms *DurationHistograms, logger micrologger.Logger) (*TransitionTime, error) {
 	if ctrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "ctrlClient must not be empty")
 	}
+	if histograms == nil {
+		return nil, microerror.Maskf(invalidConfigError, "histograms must not be empty")
+	}
 	if logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "logger must not be empty")
 	}
 
 	u := &TransitionTime{
 		ctrlClient: ctrlClient,
+		histograms: histograms,
 		logger:     logger,
+
+		upgrades: newUpgradeTracker(),
 	}
 
 	return u, nil
@@ -53,6 +105,8 @@ func (t *TransitionTime) Collect(ctx context.Context, cluster *capiv1beta1.Clust
 		return nil
 	}
 
+	t.collectUpgrade(ctx, cluster, ch)
+
 	if !conditions.IsFalse(cluster, aeconditions.CreatingCondition) {
 		t.logger.Debugf(ctx, "Cluster %#q has no %#q condition or it's still being created. Skipping", cluster.Name, aeconditions.CreatingCondition)
 		return nil
@@ -67,10 +121,118 @@ func (t *TransitionTime) Collect(ctx context.Context, cluster *capiv1beta1.Clust
 		releaseVersion,
 	)
 
+	// Clusters which existed before the Creating condition was introduced have
+	// no meaningful creation duration.
+	if conditions.GetReason(cluster, aeconditions.CreatingCondition) == aeconditions.CreationCompletedReason {
+		t.observe(ctx, histogramCreate, releaseVersion, histogramCreate+"/"+string(cluster.UID), creatingLastTransition.Time, creatingLastTransition.Sub(cluster.CreationTimestamp.Time))
+	}
+
+	return nil
+}
+
+// collectUpgrade exposes for how long the cluster has been upgrading and how
+// long its latest completed upgrade took.
+func (t *TransitionTime) collectUpgrade(ctx context.Context, cluster *capiv1beta1.Cluster, ch chan<- prometheus.Metric) {
+	inProgress, completed := t.upgrades.observe(cluster)
+
+	if inProgress != nil {
+		ch <- prometheus.MustNewConstMetric(
+			clusterUpgradingDesc,
+			prometheus.GaugeValue,
+			time.Since(inProgress.Started).Seconds(),
+			cluster.Name,
+			inProgress.From,
+			inProgress.To,
+		)
+	}
+
+	if completed != nil {
+		ch <- prometheus.MustNewConstMetric(
+			clusterTransitionUpgradeDesc,
+			prometheus.GaugeValue,
+			completed.Duration().Seconds(),
+			cluster.Name,
+			completed.From,
+			completed.To,
+		)
+
+		id := fmt.Sprintf("%s/%s/%s/%d", histogramUpgrade, cluster.UID, completed.To, completed.Finished.Unix())
+		t.observe(ctx, histogramUpgrade, completed.To, id, completed.Finished, completed.Duration())
+	}
+}
+
+func (t *TransitionTime) observe(ctx context.Context, name, releaseVersion, id string, finished time.Time, d time.Duration) {
+	err := t.histograms.Observe(ctx, name, releaseVersion, id, finished, d)
+	if err != nil {
+		t.logger.Errorf(ctx, err, "Unable to record %s duration of event %#q", name, id)
+	}
+}
+
+// CollectAggregate exposes the histograms of the creation and upgrade
+// durations of all clusters. It runs after all clusters were collected, so
+// upgrades of clusters which were not observed are forgotten. Every replica
+// records its observations in the shared ConfigMap, but only one exposes them.
+// Histograms kept in memory only hold the observations of this replica and
+// are always exposed.
+func (t *TransitionTime) CollectAggregate(ctx context.Context, expose bool, ch chan<- prometheus.Metric) error {
+	t.upgrades.sweep()
+
+	if !expose && t.histograms.shared() {
+		return nil
+	}
+
+	err := t.histograms.Sync(ctx)
+	if err != nil {
+		t.logger.Errorf(ctx, err, "Unable to sync duration histograms, exposing the ones in memory")
+	}
+
+	t.histograms.collect(ch, histogramCreate, clusterCreateDurationDesc)
+	t.histograms.collect(ch, histogramUpgrade, clusterUpgradeDurationDesc)
+
 	return nil
 }
 
 func (t *TransitionTime) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- clusterTransitionCreateDesc
+	ch <- clusterTransitionUpgradeDesc
+	ch <- clusterUpgradingDesc
+	ch <- clusterCreateDurationDesc
+	ch <- clusterUpgradeDurationDesc
+	return nil
+}
+
+func (t *TransitionTime) Name() string {
+	return "transition_time"
+}
+
+// CollectVintage exposes the time it took to create a vintage cluster, which
+// ends when the Created condition is added to the AzureConfig status.
+func (t *TransitionTime) CollectVintage(ctx context.Context, cr *providerv1alpha1.AzureConfig, ch chan<- prometheus.Metric) error {
+	releaseVersion, ok := vintageReleaseVersion(cr)
+	if !ok {
+		t.logger.Debugf(ctx, "AzureConfig %#q has no %#q label nor version in its status. Skipping", cr.Name, label.ReleaseVersion)
+		return nil
+	}
+
+	if !cr.Status.Cluster.HasCreatedCondition() {
+		t.logger.Debugf(ctx, "AzureConfig %#q has no %#q condition. Skipping", cr.Name, providerv1alpha1.StatusClusterTypeCreated)
+		return nil
+	}
+
+	createdLastTransition := cr.Status.Cluster.GetCreatedCondition().LastTransitionTime
+	if createdLastTransition.IsZero() {
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		clusterTransitionCreateDesc,
+		prometheus.GaugeValue,
+		createdLastTransition.Sub(cr.CreationTimestamp.Time).Seconds(),
+		cr.Name,
+		releaseVersion,
+	)
+
+	t.observe(ctx, histogramCreate, releaseVersion, histogramCreate+"/"+string(cr.UID), createdLastTransition.Time, createdLastTransition.Sub(cr.CreationTimestamp.Time))
+
 	return nil
 }
@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_TransitionTime_CollectAggregate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		configMapName string
+		expose        bool
+		expected      int
+	}{
+		{
+			name:          "case 0: shared histograms are exposed by the replica owning the aggregates",
+			configMapName: "azure-collector-histograms",
+			expose:        true,
+			expected:      1,
+		},
+		{
+			name:          "case 1: shared histograms are not exposed by other replicas",
+			configMapName: "azure-collector-histograms",
+			expose:        false,
+			expected:      0,
+		},
+		{
+			name:     "case 2: histograms in memory are always exposed",
+			expose:   false,
+			expected: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			ctx := context.Background()
+
+			var histograms *DurationHistograms
+			{
+				c := DurationHistogramsConfig{
+					K8sClient: k8sfake.NewSimpleClientset(&corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "azure-collector-histograms",
+							Namespace: "giantswarm",
+						},
+					}),
+					Logger: microloggertest.New(),
+
+					ConfigMapName:      tc.configMapName,
+					ConfigMapNamespace: "giantswarm",
+				}
+
+				var err error
+				histograms, err = NewDurationHistograms(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := histograms.Observe(ctx, histogramCreate, "20.0.0", "create/a", time.Now(), 20*time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			transition, err := NewTransitionTime(fake.NewClientBuilder().Build(), histograms, microloggertest.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ch := make(chan prometheus.Metric, 10)
+			err = transition.CollectAggregate(ctx, tc.expose, ch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			close(ch)
+
+			var count int
+			for range ch {
+				count++
+			}
+
+			if count != tc.expected {
+				t.Fatalf("expected %d metrics, got %d", tc.expected, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/annotation"
+	aeconditions "github.com/giantswarm/apiextensions/v6/pkg/conditions"
+	"github.com/giantswarm/apiextensions/v6/pkg/label"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// upgrade is an upgrade of a cluster from one release version to another.
+type upgrade struct {
+	From     string
+	To       string
+	Started  time.Time
+	Finished time.Time
+}
+
+func (u upgrade) Duration() time.Duration {
+	return u.Finished.Sub(u.Started)
+}
+
+// upgradeTracker remembers when clusters started upgrading, since the
+// Upgrading condition only holds the time of its latest transition. Upgrades
+// which started before the collector did are therefore not reported as
+// completed.
+type upgradeTracker struct {
+	mutex      sync.Mutex
+	inProgress map[string]upgrade
+	completed  map[string]upgrade
+	// observed holds the clusters observed since the last sweep.
+	observed map[string]bool
+}
+
+func newUpgradeTracker() *upgradeTracker {
+	return &upgradeTracker{
+		inProgress: map[string]upgrade{},
+		completed:  map[string]upgrade{},
+		observed:   map[string]bool{},
+	}
+}
+
+// observe updates the upgrade tracked for the given cluster based on its
+// Upgrading condition. It returns the upgrade in progress and the latest
+// completed upgrade, if any.
+func (t *upgradeTracker) observe(cluster *capiv1beta1.Cluster) (*upgrade, *upgrade) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !cluster.DeletionTimestamp.IsZero() {
+		delete(t.inProgress, cluster.Name)
+		delete(t.completed, cluster.Name)
+		return nil, nil
+	}
+
+	t.observed[cluster.Name] = true
+
+	switch {
+	case conditions.IsTrue(cluster, aeconditions.UpgradingCondition):
+		started := conditions.GetLastTransitionTime(cluster, aeconditions.UpgradingCondition)
+
+		// The versions are only taken when the upgrade is first seen, since
+		// the last deployed release version annotation may already be
+		// updated while the condition is still true.
+		if u, ok := t.inProgress[cluster.Name]; ok && u.Started.Equal(started.Time) {
+			break
+		}
+
+		u := upgrade{
+			From:    cluster.Annotations[annotation.LastDeployedReleaseVersion],
+			To:      upgradeTargetVersion(cluster),
+			Started: started.Time,
+		}
+		t.inProgress[cluster.Name] = u
+
+	case conditions.IsFalse(cluster, aeconditions.UpgradingCondition):
+		u, ok := t.inProgress[cluster.Name]
+		if !ok {
+			break
+		}
+		delete(t.inProgress, cluster.Name)
+
+		if conditions.GetReason(cluster, aeconditions.UpgradingCondition) != aeconditions.UpgradeCompletedReason {
+			break
+		}
+
+		u.Finished = conditions.GetLastTransitionTime(cluster, aeconditions.UpgradingCondition).Time
+		t.completed[cluster.Name] = u
+	}
+
+	var inProgress, completed *upgrade
+	if u, ok := t.inProgress[cluster.Name]; ok {
+		inProgress = &u
+	}
+	if u, ok := t.completed[cluster.Name]; ok {
+		completed = &u
+	}
+
+	return inProgress, completed
+}
+
+// sweep forgets the upgrades of clusters which were not observed since the
+// previous sweep, e.g. because they were deleted without their deletion being
+// seen or because they are no longer owned by this shard.
+func (t *upgradeTracker) sweep() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for name := range t.inProgress {
+		if !t.observed[name] {
+			delete(t.inProgress, name)
+		}
+	}
+	for name := range t.completed {
+		if !t.observed[name] {
+			delete(t.completed, name)
+		}
+	}
+
+	t.observed = map[string]bool{}
+}
+
+// upgradeTargetVersion returns the release version a cluster is upgrading to,
+// as given in the message of its Upgrading condition, falling back to its
+// release label.
+func upgradeTargetVersion(cluster *capiv1beta1.Cluster) string {
+	c := conditions.Get(cluster, aeconditions.UpgradingCondition)
+	if c != nil && c.Message != "" {
+		message, err := aeconditions.DeserializeUpgradingConditionMessage(c.Message)
+		if err == nil && message.ReleaseVersion != "" {
+			return message.ReleaseVersion
+		}
+	}
+
+	return cluster.Labels[label.ReleaseVersion]
+}
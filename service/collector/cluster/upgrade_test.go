@@ -0,0 +1,105 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/annotation"
+	aeconditions "github.com/giantswarm/apiextensions/v6/pkg/conditions"
+	"github.com/giantswarm/apiextensions/v6/pkg/label"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func Test_upgradeTracker_observe(t *testing.T) {
+	started := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	finished := started.Add(25 * time.Minute)
+
+	message, err := aeconditions.SerializeUpgradingConditionMessage(aeconditions.UpgradingConditionMessage{ReleaseVersion: "20.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newCluster := func(status corev1.ConditionStatus, reason string, transition time.Time) *capiv1beta1.Cluster {
+		return &capiv1beta1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "abc12",
+				Annotations: map[string]string{annotation.LastDeployedReleaseVersion: "19.0.0"},
+				Labels:      map[string]string{label.ReleaseVersion: "20.0.0"},
+			},
+			Status: capiv1beta1.ClusterStatus{
+				Conditions: capiv1beta1.Conditions{
+					{
+						Type:               aeconditions.UpgradingCondition,
+						Status:             status,
+						Reason:             reason,
+						Message:            message,
+						LastTransitionTime: metav1.NewTime(transition),
+					},
+				},
+			},
+		}
+	}
+
+	tracker := newUpgradeTracker()
+
+	// A completed upgrade which started before it was tracked is ignored.
+	inProgress, completed := tracker.observe(newCluster(corev1.ConditionFalse, aeconditions.UpgradeCompletedReason, started))
+	if inProgress != nil || completed != nil {
+		t.Fatalf("expected no upgrade, got %v and %v", inProgress, completed)
+	}
+
+	inProgress, completed = tracker.observe(newCluster(corev1.ConditionTrue, "", started))
+	if completed != nil {
+		t.Fatalf("expected no completed upgrade, got %v", completed)
+	}
+	if inProgress == nil || inProgress.From != "19.0.0" || inProgress.To != "20.0.0" || !inProgress.Started.Equal(started) {
+		t.Fatalf("unexpected upgrade in progress %v", inProgress)
+	}
+
+	// The versions of an upgrade in progress are kept when the annotation is
+	// updated before the upgrade completes.
+	updated := newCluster(corev1.ConditionTrue, "", started)
+	updated.Annotations[annotation.LastDeployedReleaseVersion] = "20.0.0"
+
+	inProgress, _ = tracker.observe(updated)
+	if inProgress == nil || inProgress.From != "19.0.0" || inProgress.To != "20.0.0" {
+		t.Fatalf("unexpected upgrade in progress %v", inProgress)
+	}
+
+	for i := 0; i < 2; i++ {
+		inProgress, completed = tracker.observe(newCluster(corev1.ConditionFalse, aeconditions.UpgradeCompletedReason, finished))
+		if inProgress != nil {
+			t.Fatalf("expected no upgrade in progress, got %v", inProgress)
+		}
+		if completed == nil || completed.From != "19.0.0" || completed.To != "20.0.0" || completed.Duration() != 25*time.Minute {
+			t.Fatalf("unexpected completed upgrade %v", completed)
+		}
+	}
+
+	// Upgrades of clusters which are observed between sweeps are kept.
+	tracker.sweep()
+	_, completed = tracker.observe(newCluster(corev1.ConditionFalse, aeconditions.UpgradeCompletedReason, finished))
+	if completed == nil {
+		t.Fatalf("expected completed upgrade after sweep, got none")
+	}
+
+	deleted := newCluster(corev1.ConditionFalse, aeconditions.UpgradeCompletedReason, finished)
+	deleted.DeletionTimestamp = &metav1.Time{Time: finished}
+
+	inProgress, completed = tracker.observe(deleted)
+	if inProgress != nil || completed != nil {
+		t.Fatalf("expected no upgrade for deleted cluster, got %v and %v", inProgress, completed)
+	}
+
+	// Upgrades of clusters which disappear without their deletion being seen
+	// are forgotten by the sweep of the first collection not observing them.
+	tracker.observe(newCluster(corev1.ConditionTrue, "", started))
+	tracker.sweep()
+	tracker.sweep()
+
+	if len(tracker.inProgress) != 0 || len(tracker.completed) != 0 {
+		t.Fatalf("expected no tracked upgrades, got %v and %v", tracker.inProgress, tracker.completed)
+	}
+}
@@ -0,0 +1,284 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
+)
+
+const (
+	credentialHealthCollectorName = "credential_health"
+	credentialHealthSubsystem     = "credential_secret"
+
+	labelSecretName      = "secret_name"
+	labelSecretNamespace = "secret_namespace"
+	labelKey             = "key"
+	labelErrorCode       = "error_code"
+
+	// credentialTokenTTL is how long the result of a token acquisition is
+	// reused before AAD is asked again. Changed secrets are checked right
+	// away because the cache is keyed by resource version.
+	credentialTokenTTL = 10 * time.Minute
+	// credentialTokenTimeout bounds every token acquisition so that a slow
+	// AAD endpoint does not stall the whole scrape.
+	credentialTokenTimeout = 30 * time.Second
+)
+
+var (
+	credentialTokenAcquiredDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, credentialHealthSubsystem, "token_acquired"),
+		"Whether an access token could be acquired with the service principal of the credential secret.",
+		[]string{
+			labelSecretName,
+			labelSecretNamespace,
+			labelClientId,
+			labelSubscriptionId,
+			labelTenantId,
+		},
+		nil,
+	)
+	credentialTokenErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, credentialHealthSubsystem, "token_error"),
+		"Azure Active Directory error code returned when acquiring an access token for the credential secret failed.",
+		[]string{
+			labelSecretName,
+			labelSecretNamespace,
+			labelClientId,
+			labelSubscriptionId,
+			labelTenantId,
+			labelErrorCode,
+		},
+		nil,
+	)
+	credentialKeyPresentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, credentialHealthSubsystem, "key_present"),
+		"Whether a required key is present in the credential secret.",
+		[]string{
+			labelSecretName,
+			labelSecretNamespace,
+			labelKey,
+		},
+		nil,
+	)
+	credentialPartnerIDFallbackDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, credentialHealthSubsystem, "partner_id_fallback"),
+		"Whether the credential secret has no partner ID and the default Azure GUID is used instead.",
+		[]string{
+			labelSecretName,
+			labelSecretNamespace,
+		},
+		nil,
+	)
+	credentialSingleTenantDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, credentialHealthSubsystem, "single_tenant"),
+		"Whether the credential secret uses a single tenant service principal.",
+		[]string{
+			labelSecretName,
+			labelSecretNamespace,
+		},
+		nil,
+	)
+)
+
+type CredentialHealthConfig struct {
+	CtrlClient client.Client
+	Inventory  *inventory.Inventory
+	Logger     micrologger.Logger
+	Shard      sharding.Interface
+	GSTenantID string
+}
+
+type CredentialHealth struct {
+	ctrlClient client.Client
+	inventory  *inventory.Inventory
+	logger     micrologger.Logger
+	shard      sharding.Interface
+	gsTenantID string
+
+	mutex  sync.Mutex
+	tokens map[string]tokenResult
+}
+
+type tokenResult struct {
+	err        error
+	acquiredAt time.Time
+}
+
+// NewCredentialHealth exposes metrics about the health of the "credential-*" secrets of the control plane.
+// It resolves every secret the same way the other collectors do, so broken or half-migrated secrets show up before operators fail.
+func NewCredentialHealth(config CredentialHealthConfig) (*CredentialHealth, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
+	if config.GSTenantID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
+	}
+
+	c := &CredentialHealth{
+		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
+		logger:     config.Logger,
+		shard:      config.Shard,
+		gsTenantID: config.GSTenantID,
+
+		mutex:  sync.Mutex{},
+		tokens: map[string]tokenResult{},
+	}
+
+	return c, nil
+}
+
+func (c *CredentialHealth) Collect(ch chan<- prometheus.Metric) error {
+	ctx := context.Background()
+
+	secrets, err := credential.GetCredentialSecrets(ctx, c.ctrlClient)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	seen := map[string]bool{}
+	for i := range secrets {
+		secret := &secrets[i]
+		health := credential.GetSecretHealth(secret, c.gsTenantID)
+		if !c.shard.Owns(health.SubscriptionID) {
+			continue
+		}
+
+		for _, k := range credential.RequiredKeys {
+			ch <- prometheus.MustNewConstMetric(
+				credentialKeyPresentDesc,
+				prometheus.GaugeValue,
+				boolToFloat64(health.PresentKeys[k]),
+				secret.Name,
+				secret.Namespace,
+				k,
+			)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			credentialPartnerIDFallbackDesc,
+			prometheus.GaugeValue,
+			boolToFloat64(health.PartnerIDFallback),
+			secret.Name,
+			secret.Namespace,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			credentialSingleTenantDesc,
+			prometheus.GaugeValue,
+			boolToFloat64(health.SingleTenant),
+			secret.Name,
+			secret.Namespace,
+		)
+
+		key := tokenCacheKey(secret)
+		seen[key] = true
+
+		err := c.acquireToken(ctx, key, secret)
+		if health.SubscriptionID != "" {
+			c.inventory.RecordSubscription(health.SubscriptionID, credentialHealthCollectorName, err)
+		}
+		if err != nil {
+			c.logger.Debugf(ctx, "unable to acquire token for credential secret %s/%s: %s", secret.Namespace, secret.Name, err.Error())
+
+			ch <- prometheus.MustNewConstMetric(
+				credentialTokenErrorDesc,
+				prometheus.GaugeValue,
+				1,
+				secret.Name,
+				secret.Namespace,
+				health.ClientID,
+				health.SubscriptionID,
+				health.TenantID,
+				credential.AADErrorCode(err),
+			)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			credentialTokenAcquiredDesc,
+			prometheus.GaugeValue,
+			boolToFloat64(err == nil),
+			secret.Name,
+			secret.Namespace,
+			health.ClientID,
+			health.SubscriptionID,
+			health.TenantID,
+		)
+	}
+
+	c.mutex.Lock()
+	for key := range c.tokens {
+		if !seen[key] {
+			delete(c.tokens, key)
+		}
+	}
+	c.mutex.Unlock()
+
+	return nil
+}
+
+func (c *CredentialHealth) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- credentialTokenAcquiredDesc
+	ch <- credentialTokenErrorDesc
+	ch <- credentialKeyPresentDesc
+	ch <- credentialPartnerIDFallbackDesc
+	ch <- credentialSingleTenantDesc
+	return nil
+}
+
+// acquireToken returns the cached result of the last token acquisition for
+// the given secret, or asks AAD again once credentialTokenTTL passed.
+func (c *CredentialHealth) acquireToken(ctx context.Context, key string, secret *v1.Secret) error {
+	c.mutex.Lock()
+	cached, ok := c.tokens[key]
+	c.mutex.Unlock()
+
+	if ok && time.Since(cached.acquiredAt) < credentialTokenTTL {
+		return cached.err
+	}
+
+	tokenCtx, cancel := context.WithTimeout(ctx, credentialTokenTimeout)
+	defer cancel()
+
+	err := credential.AcquireTokenFromSecret(tokenCtx, secret, c.gsTenantID)
+
+	c.mutex.Lock()
+	c.tokens[key] = tokenResult{
+		err:        err,
+		acquiredAt: time.Now(),
+	}
+	c.mutex.Unlock()
+
+	return err
+}
+
+func tokenCacheKey(secret *v1.Secret) string {
+	return secret.Namespace + "/" + secret.Name + "@" + secret.ResourceVersion
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
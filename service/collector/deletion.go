@@ -0,0 +1,363 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capzv1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/azure-collector/v3/client"
+	"github.com/giantswarm/azure-collector/v3/internal/capzcredentials"
+	"github.com/giantswarm/azure-collector/v3/service/collector/key"
+	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
+)
+
+const (
+	deletionCollectorName = "deletion"
+	deletionSubsystem     = "deletion"
+
+	kindAzureCluster = "AzureCluster"
+	kindAzureConfig  = "AzureConfig"
+	kindCluster      = "Cluster"
+	kindMachinePool  = "MachinePool"
+
+	labelClusterID     = "cluster_id"
+	labelFinalizer     = "finalizer"
+	labelKind          = "kind"
+	labelNamespace     = "namespace"
+	labelResourceGroup = "resource_group"
+)
+
+var (
+	deletionDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, deletionSubsystem, "duration_seconds"),
+		"Time in seconds since the deletion of a cluster CR was requested.",
+		[]string{
+			labelKind,
+			labelNamespace,
+			labelName,
+			labelClusterID,
+		},
+		nil,
+	)
+	deletionFinalizerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, deletionSubsystem, "finalizer"),
+		"Finalizer blocking the deletion of a cluster CR. The value is always 1.",
+		[]string{
+			labelKind,
+			labelNamespace,
+			labelName,
+			labelClusterID,
+			labelFinalizer,
+		},
+		nil,
+	)
+	deletionResourceGroupExistsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, deletionSubsystem, "resource_group_exists"),
+		"Whether the resource group of a cluster being deleted still exists.",
+		[]string{
+			labelClusterID,
+			labelSubscriptionId,
+			labelResourceGroup,
+		},
+		nil,
+	)
+)
+
+type DeletionConfig struct {
+	CtrlClient ctrlclient.Client
+	Inventory  *inventory.Inventory
+	Logger     micrologger.Logger
+	Shard      sharding.Interface
+	GSTenantID string
+}
+
+type Deletion struct {
+	ctrlClient ctrlclient.Client
+	inventory  *inventory.Inventory
+	logger     micrologger.Logger
+	shard      sharding.Interface
+	gsTenantID string
+}
+
+// NewDeletion exposes metrics about the CAPI and vintage cluster CRs being deleted, so that deletions hanging on finalizers can be found.
+// For clusters being deleted it also checks whether their resource group is still there.
+func NewDeletion(config DeletionConfig) (*Deletion, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
+	if config.GSTenantID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
+	}
+
+	d := &Deletion{
+		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
+		logger:     config.Logger,
+		shard:      config.Shard,
+		gsTenantID: config.GSTenantID,
+	}
+
+	return d, nil
+}
+
+// deletingObject is a cluster CR with a deletion timestamp.
+type deletingObject struct {
+	Kind       string
+	Namespace  string
+	Name       string
+	ClusterID  string
+	Deleting   time.Time
+	Finalizers []string
+}
+
+func (d *Deletion) Collect(ch chan<- prometheus.Metric) error {
+	ctx := context.Background()
+
+	objects, err := d.getDeletingObjects(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	now := time.Now()
+
+	for _, o := range objects {
+		ch <- prometheus.MustNewConstMetric(
+			deletionDurationDesc,
+			prometheus.GaugeValue,
+			now.Sub(o.Deleting).Seconds(),
+			o.Kind,
+			o.Namespace,
+			o.Name,
+			o.ClusterID,
+		)
+
+		for _, f := range o.Finalizers {
+			ch <- prometheus.MustNewConstMetric(
+				deletionFinalizerDesc,
+				prometheus.GaugeValue,
+				1,
+				o.Kind,
+				o.Namespace,
+				o.Name,
+				o.ClusterID,
+				f,
+			)
+		}
+
+		var err error
+		switch o.Kind {
+		case kindCluster:
+			err = d.collectCAPIResourceGroup(ctx, ch, o)
+		case kindAzureConfig:
+			err = d.collectVintageResourceGroup(ctx, ch, o)
+		default:
+			continue
+		}
+
+		d.inventory.Record(o.ClusterID, deletionCollectorName, err)
+	}
+
+	return nil
+}
+
+func (d *Deletion) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- deletionDurationDesc
+	ch <- deletionFinalizerDesc
+	ch <- deletionResourceGroupExistsDesc
+	return nil
+}
+
+// getDeletingObjects returns the Cluster, AzureCluster, MachinePool and
+// AzureConfig CRs being deleted which belong to clusters owned by this
+// replica.
+func (d *Deletion) getDeletingObjects(ctx context.Context) ([]deletingObject, error) {
+	var objects []deletingObject
+
+	add := func(kind, clusterID string, obj metav1.ObjectMeta) {
+		if obj.DeletionTimestamp.IsZero() || !d.shard.Owns(clusterID) {
+			return
+		}
+
+		finalizers := append([]string{}, obj.Finalizers...)
+		sort.Strings(finalizers)
+
+		objects = append(objects, deletingObject{
+			Kind:       kind,
+			Namespace:  obj.Namespace,
+			Name:       obj.Name,
+			ClusterID:  clusterID,
+			Deleting:   obj.DeletionTimestamp.Time,
+			Finalizers: finalizers,
+		})
+	}
+
+	{
+		list := &capiv1beta1.ClusterList{}
+		err := d.ctrlClient.List(ctx, list, ctrlclient.InNamespace(metav1.NamespaceAll))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, cr := range list.Items {
+			add(kindCluster, cr.Name, cr.ObjectMeta)
+		}
+	}
+
+	{
+		list := &capzv1beta1.AzureClusterList{}
+		err := d.ctrlClient.List(ctx, list, ctrlclient.InNamespace(metav1.NamespaceAll))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, cr := range list.Items {
+			add(kindAzureCluster, cr.Labels[capiv1beta1.ClusterLabelName], cr.ObjectMeta)
+		}
+	}
+
+	{
+		list := &v1alpha4.MachinePoolList{}
+		err := d.ctrlClient.List(ctx, list, ctrlclient.InNamespace(metav1.NamespaceAll))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, cr := range list.Items {
+			add(kindMachinePool, cr.Spec.ClusterName, cr.ObjectMeta)
+		}
+	}
+
+	{
+		mark := ""
+		page := 0
+		for page == 0 || len(mark) > 0 {
+			opts := ctrlclient.ListOptions{
+				Continue: mark,
+			}
+			list := providerv1alpha1.AzureConfigList{}
+			err := d.ctrlClient.List(ctx, &list, &opts)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, cr := range list.Items {
+				add(kindAzureConfig, cr.Name, cr.ObjectMeta)
+			}
+
+			mark = list.Continue
+			page++
+		}
+	}
+
+	return objects, nil
+}
+
+// collectCAPIResourceGroup exposes whether the resource group of the
+// AzureCluster of the given Cluster being deleted still exists.
+func (d *Deletion) collectCAPIResourceGroup(ctx context.Context, ch chan<- prometheus.Metric, o deletingObject) error {
+	cluster := &capiv1beta1.Cluster{}
+	err := d.ctrlClient.Get(ctx, ctrlclient.ObjectKey{Namespace: o.Namespace, Name: o.Name}, cluster)
+	if err != nil {
+		d.logger.Errorf(ctx, err, "Unable to get cluster %q", o.Name)
+		return microerror.Mask(err)
+	}
+
+	resourceGroup := cluster.Name
+	if ref := cluster.Spec.InfrastructureRef; ref != nil && ref.Kind == kindAzureCluster {
+		azureCluster := &capzv1beta1.AzureCluster{}
+		err = d.ctrlClient.Get(ctx, ctrlclient.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, azureCluster)
+		if err != nil {
+			d.logger.Errorf(ctx, err, "Unable to get AzureCluster for cluster %q", cluster.Name)
+			return microerror.Mask(err)
+		}
+		if azureCluster.Spec.ResourceGroup != "" {
+			resourceGroup = azureCluster.Spec.ResourceGroup
+		}
+	}
+
+	azureCredentials, err := capzcredentials.GetAzureCredentialsFromMetadata(ctx, d.ctrlClient, cluster.ObjectMeta)
+	if err != nil {
+		d.logger.Errorf(ctx, err, "Unable to get azure credentials for cluster %q", cluster.Name)
+		return microerror.Mask(err)
+	}
+
+	settings := auth.NewClientCredentialsConfig(azureCredentials.ClientID, azureCredentials.ClientSecret, azureCredentials.TenantID)
+	authorizer, err := settings.Authorizer()
+	if err != nil {
+		d.logger.Errorf(ctx, err, "Unable to use azure credentials for cluster %q", cluster.Name)
+		return microerror.Mask(err)
+	}
+
+	groupsClient := resources.NewGroupsClient(azureCredentials.SubscriptionID)
+	groupsClient.Client.Authorizer = authorizer
+
+	return d.collectResourceGroupExists(ctx, ch, o.ClusterID, azureCredentials.SubscriptionID, resourceGroup, &groupsClient)
+}
+
+// collectVintageResourceGroup exposes whether the resource group of the given
+// AzureConfig being deleted still exists.
+func (d *Deletion) collectVintageResourceGroup(ctx context.Context, ch chan<- prometheus.Metric, o deletingObject) error {
+	cr := providerv1alpha1.AzureConfig{}
+	err := d.ctrlClient.Get(ctx, ctrlclient.ObjectKey{Namespace: o.Namespace, Name: o.Name}, &cr)
+	if err != nil {
+		d.logger.Errorf(ctx, err, "Unable to get AzureConfig %q", o.Name)
+		return microerror.Mask(err)
+	}
+
+	config, err := credential.GetAzureConfigFromSecretName(ctx, d.ctrlClient, key.CredentialName(cr), key.CredentialNamespace(cr), d.gsTenantID)
+	if err != nil {
+		d.logger.Errorf(ctx, err, "Unable to get azure credentials for cluster %q", cr.Name)
+		return microerror.Mask(err)
+	}
+
+	clientSet, err := client.NewAzureClientSet(*config)
+	if err != nil {
+		d.logger.Errorf(ctx, err, "Unable to use azure credentials for cluster %q", cr.Name)
+		return microerror.Mask(err)
+	}
+
+	return d.collectResourceGroupExists(ctx, ch, o.ClusterID, config.SubscriptionID, cr.Name, clientSet.GroupsClient)
+}
+
+func (d *Deletion) collectResourceGroupExists(ctx context.Context, ch chan<- prometheus.Metric, clusterID, subscriptionID, resourceGroup string, groupsClient *resources.GroupsClient) error {
+	resp, err := groupsClient.CheckExistence(ctx, resourceGroup)
+	if err != nil {
+		d.logger.Errorf(ctx, err, "Unable to check existence of resource group %q of cluster %q", resourceGroup, clusterID)
+		return microerror.Mask(err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		deletionResourceGroupExistsDesc,
+		prometheus.GaugeValue,
+		boolToFloat64(resp.StatusCode != http.StatusNotFound),
+		clusterID,
+		subscriptionID,
+		resourceGroup,
+	)
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/micrologger/microloggertest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	capzv1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
+)
+
+func Test_Deletion_getDeletingObjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		capiv1beta1.AddToScheme,
+		capzv1beta1.AddToScheme,
+		providerv1alpha1.AddToScheme,
+		v1alpha4.AddToScheme,
+	} {
+		err := addToScheme(scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleting := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	objects := []runtime.Object{
+		&capiv1beta1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "abc12", Namespace: "org-test", DeletionTimestamp: &deleting, Finalizers: []string{"cluster.cluster.x-k8s.io"}},
+		},
+		&capiv1beta1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "def34", Namespace: "org-test"},
+		},
+		&capzv1beta1.AzureCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "abc12",
+				Namespace:         "org-test",
+				Labels:            map[string]string{capiv1beta1.ClusterLabelName: "abc12"},
+				DeletionTimestamp: &deleting,
+				Finalizers:        []string{"azurecluster.infrastructure.cluster.x-k8s.io", "operatorkit.giantswarm.io/azure-operator"},
+			},
+		},
+		&v1alpha4.MachinePool{
+			ObjectMeta: metav1.ObjectMeta{Name: "np001", Namespace: "org-test", DeletionTimestamp: &deleting, Finalizers: []string{"machinepool.cluster.x-k8s.io"}},
+			Spec:       v1alpha4.MachinePoolSpec{ClusterName: "abc12"},
+		},
+		&providerv1alpha1.AzureConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "ghi56", Namespace: "default", DeletionTimestamp: &deleting, Finalizers: []string{"operatorkit.giantswarm.io/azure-operator"}},
+		},
+	}
+
+	c := DeletionConfig{
+		CtrlClient: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+		Inventory:  inventory.New(),
+		Logger:     microloggertest.New(),
+		Shard:      sharding.All{},
+		GSTenantID: gsTenantID,
+	}
+
+	d, err := NewDeletion(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deletingObjects, err := d.getDeletingObjects(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{
+		kindCluster + "/abc12":      1,
+		kindAzureCluster + "/abc12": 2,
+		kindMachinePool + "/abc12":  1,
+		kindAzureConfig + "/ghi56":  1,
+	}
+
+	if len(deletingObjects) != len(expected) {
+		t.Fatalf("expected %d objects being deleted, got %v", len(expected), deletingObjects)
+	}
+
+	for _, o := range deletingObjects {
+		finalizers, ok := expected[o.Kind+"/"+o.ClusterID]
+		if !ok {
+			t.Fatalf("unexpected object being deleted %v", o)
+		}
+		if len(o.Finalizers) != finalizers {
+			t.Fatalf("expected %d finalizers for %s %s, got %v", finalizers, o.Kind, o.Name, o.Finalizers)
+		}
+		if !o.Deleting.Equal(deleting.Time.Truncate(time.Second)) {
+			t.Fatalf("unexpected deletion timestamp %v", o.Deleting)
+		}
+	}
+}
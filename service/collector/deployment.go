@@ -2,21 +2,45 @@ package collector
 
 import (
 	"context"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources" //nolint:staticcheck
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/giantswarm/azure-collector/v3/client"
 	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
 )
 
 const (
-	statusCanceled  = "Canceled"
-	statusFailed    = "Failed"
-	statusRunning   = "Running"
-	statusSucceeded = "Succeeded"
+	deploymentCollectorName = "deployment"
+
+	statusAccepted     = "Accepted"
+	statusCanceled     = "Canceled"
+	statusCreated      = "Created"
+	statusCreating     = "Creating"
+	statusDeleted      = "Deleted"
+	statusDeleting     = "Deleting"
+	statusFailed       = "Failed"
+	statusNotSpecified = "NotSpecified"
+	statusReady        = "Ready"
+	statusRunning      = "Running"
+	statusSucceeded    = "Succeeded"
+	statusUpdating     = "Updating"
+	statusValidating   = "Validating"
+	statusWaiting      = "Waiting"
+
+	labelResourceType = "resource_type"
 )
 
 var (
@@ -30,36 +54,103 @@ var (
 		},
 		nil,
 	)
+	deploymentDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "deployment", "duration_seconds"),
+		"Duration in seconds of the latest deployment of a template, which is still increasing while it is running.",
+		[]string{
+			"cluster_id",
+			"deployment_name",
+		},
+		nil,
+	)
+	deploymentTimestampDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "deployment", "timestamp_seconds"),
+		"Unix timestamp of the last change of the latest deployment of a template.",
+		[]string{
+			"cluster_id",
+			"deployment_name",
+		},
+		nil,
+	)
+	deploymentFailureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "deployment", "failure"),
+		"Error code and type of the failing resource of the latest deployment of a template which failed. The value is always 1.",
+		[]string{
+			"cluster_id",
+			"deployment_name",
+			labelErrorCode,
+			labelResourceType,
+		},
+		nil,
+	)
+
+	// knownDeploymentStatuses are the provisioning states of ARM deployments,
+	// which are all exposed so that series do not appear and disappear.
+	knownDeploymentStatuses = []string{
+		statusAccepted,
+		statusCanceled,
+		statusCreated,
+		statusCreating,
+		statusDeleted,
+		statusDeleting,
+		statusFailed,
+		statusNotSpecified,
+		statusReady,
+		statusRunning,
+		statusSucceeded,
+		statusUpdating,
+		statusValidating,
+		statusWaiting,
+	}
+
+	// iso8601Duration matches the durations of deployments, e.g. "PT1M30.5S".
+	iso8601Duration = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+	// deploymentNameSuffix matches the numeric suffixes, e.g. counters or
+	// timestamps, of deployments which are repeated under a new name.
+	deploymentNameSuffix = regexp.MustCompile(`-\d+$`)
 )
 
 type DeploymentConfig struct {
-	CtrlClient client.Client
+	CtrlClient ctrlclient.Client
+	Inventory  *inventory.Inventory
 	Logger     micrologger.Logger
+	Shard      sharding.Interface
 	GSTenantID string
 }
 
 type Deployment struct {
-	ctrlClient client.Client
+	ctrlClient ctrlclient.Client
+	inventory  *inventory.Inventory
 	logger     micrologger.Logger
+	shard      sharding.Interface
 	gsTenantID string
 }
 
 // NewDeployment exposes metrics about the Azure ARM Deployments for every cluster on this installation.
 // It finds the cluster in the control plane, and uses the cluster Azure credentials to find the Deployments info.
+// Only the latest deployment of every template is exposed, including the error code and failing resource type of failed deployments.
 func NewDeployment(config DeploymentConfig) (*Deployment, error) {
 	if config.CtrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
 	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
 	if config.GSTenantID == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
 	}
 
 	d := &Deployment{
 		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
 		logger:     config.Logger,
+		shard:      config.Shard,
 		gsTenantID: config.GSTenantID,
 	}
 
@@ -74,46 +165,30 @@ func (d *Deployment) Collect(ch chan<- prometheus.Metric) error {
 	}
 
 	for clusterID, azureClientSet := range azureClientSets {
-		r, err := azureClientSet.DeploymentsClient.ListByResourceGroup(context.Background(), clusterID, "", to.Int32Ptr(100))
+		if !d.shard.Owns(clusterID) {
+			continue
+		}
+
+		err := d.collectForCluster(ctx, ch, clusterID, azureClientSet)
+		d.inventory.Record(clusterID, deploymentCollectorName, err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (d *Deployment) collectForCluster(ctx context.Context, ch chan<- prometheus.Metric, clusterID string, azureClientSet *client.AzureClientSet) error {
+	var deployments []resources.DeploymentExtended
+	{
+		r, err := azureClientSet.DeploymentsClient.ListByResourceGroupComplete(ctx, clusterID, "", nil)
 		if err != nil {
 			return microerror.Mask(err)
 		}
 
 		for r.NotDone() {
-			for _, v := range r.Values() {
-				ch <- prometheus.MustNewConstMetric(
-					deploymentDesc,
-					prometheus.GaugeValue,
-					float64(matchedStringToInt(statusCanceled, *v.Properties.ProvisioningState)),
-					clusterID,
-					*v.Name,
-					statusCanceled,
-				)
-				ch <- prometheus.MustNewConstMetric(
-					deploymentDesc,
-					prometheus.GaugeValue,
-					float64(matchedStringToInt(statusFailed, *v.Properties.ProvisioningState)),
-					clusterID,
-					*v.Name,
-					statusFailed,
-				)
-				ch <- prometheus.MustNewConstMetric(
-					deploymentDesc,
-					prometheus.GaugeValue,
-					float64(matchedStringToInt(statusRunning, *v.Properties.ProvisioningState)),
-					clusterID,
-					*v.Name,
-					statusRunning,
-				)
-				ch <- prometheus.MustNewConstMetric(
-					deploymentDesc,
-					prometheus.GaugeValue,
-					float64(matchedStringToInt(statusSucceeded, *v.Properties.ProvisioningState)),
-					clusterID,
-					*v.Name,
-					statusSucceeded,
-				)
-			}
+			deployments = append(deployments, r.Value())
 
 			err := r.NextWithContext(ctx)
 			if err != nil {
@@ -122,11 +197,93 @@ func (d *Deployment) Collect(ch chan<- prometheus.Metric) error {
 		}
 	}
 
+	for _, v := range latestDeployments(deployments) {
+		state := deploymentState(v)
+		for _, status := range deploymentStatuses(state) {
+			ch <- prometheus.MustNewConstMetric(
+				deploymentDesc,
+				prometheus.GaugeValue,
+				float64(matchedStringToInt(status, state)),
+				clusterID,
+				to.String(v.Name),
+				status,
+			)
+		}
+
+		d.collectDetails(ctx, ch, clusterID, azureClientSet, v)
+	}
+
 	return nil
 }
 
+// collectDetails exposes the duration and timestamp of the given deployment
+// and, if it failed, its error code and failing resource type.
+func (d *Deployment) collectDetails(ctx context.Context, ch chan<- prometheus.Metric, clusterID string, azureClientSet *client.AzureClientSet, v resources.DeploymentExtended) {
+	if v.Properties == nil {
+		return
+	}
+
+	if duration, ok := parseISO8601Duration(to.String(v.Properties.Duration)); ok {
+		ch <- prometheus.MustNewConstMetric(
+			deploymentDurationDesc,
+			prometheus.GaugeValue,
+			duration.Seconds(),
+			clusterID,
+			to.String(v.Name),
+		)
+	}
+
+	if v.Properties.Timestamp != nil {
+		ch <- prometheus.MustNewConstMetric(
+			deploymentTimestampDesc,
+			prometheus.GaugeValue,
+			float64(v.Properties.Timestamp.Unix()),
+			clusterID,
+			to.String(v.Name),
+		)
+	}
+
+	if to.String(v.Properties.ProvisioningState) != statusFailed {
+		return
+	}
+
+	var operations []resources.DeploymentOperation
+	{
+		r, err := azureClientSet.DeploymentOperationsClient.ListComplete(ctx, clusterID, to.String(v.Name), nil)
+		if err != nil {
+			d.logger.Errorf(ctx, err, "Unable to list operations of deployment %q of cluster %q", to.String(v.Name), clusterID)
+			return
+		}
+
+		for r.NotDone() {
+			operations = append(operations, r.Value())
+
+			err := r.NextWithContext(ctx)
+			if err != nil {
+				d.logger.Errorf(ctx, err, "Unable to list operations of deployment %q of cluster %q", to.String(v.Name), clusterID)
+				return
+			}
+		}
+	}
+
+	errorCode, resourceType := deploymentFailure(operations)
+
+	ch <- prometheus.MustNewConstMetric(
+		deploymentFailureDesc,
+		prometheus.GaugeValue,
+		1,
+		clusterID,
+		to.String(v.Name),
+		errorCode,
+		resourceType,
+	)
+}
+
 func (d *Deployment) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- deploymentDesc
+	ch <- deploymentDurationDesc
+	ch <- deploymentTimestampDesc
+	ch <- deploymentFailureDesc
 	return nil
 }
 
@@ -137,3 +294,165 @@ func matchedStringToInt(a, b string) int {
 
 	return 0
 }
+
+// latestDeployments returns the latest deployment of every template, sorted by
+// name. Deployments are identified by their name without numeric suffix and
+// the URI of their linked template, so that e.g. the deployments of different
+// node pools sharing a template are kept apart.
+func latestDeployments(deployments []resources.DeploymentExtended) []resources.DeploymentExtended {
+	latest := map[string]resources.DeploymentExtended{}
+	for _, v := range deployments {
+		k := deploymentKey(v)
+
+		l, ok := latest[k]
+		if !ok || deploymentTimestamp(v).After(deploymentTimestamp(l)) {
+			latest[k] = v
+		}
+	}
+
+	var result []resources.DeploymentExtended
+	for _, v := range latest {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return to.String(result[i].Name) < to.String(result[j].Name)
+	})
+
+	return result
+}
+
+// deploymentState returns the provisioning state of the given deployment,
+// which is NotSpecified when it is missing.
+func deploymentState(v resources.DeploymentExtended) string {
+	if v.Properties == nil || v.Properties.ProvisioningState == nil || *v.Properties.ProvisioningState == "" {
+		return statusNotSpecified
+	}
+
+	return *v.Properties.ProvisioningState
+}
+
+// deploymentStatuses returns the statuses to expose for a deployment in the
+// given provisioning state. States unknown to this collector are added, so
+// that they never go unnoticed.
+func deploymentStatuses(state string) []string {
+	for _, s := range knownDeploymentStatuses {
+		if s == state {
+			return knownDeploymentStatuses
+		}
+	}
+
+	statuses := append([]string{}, knownDeploymentStatuses...)
+	statuses = append(statuses, state)
+
+	return statuses
+}
+
+func deploymentKey(v resources.DeploymentExtended) string {
+	name := deploymentNameSuffix.ReplaceAllString(to.String(v.Name), "")
+
+	if v.Properties != nil && v.Properties.TemplateLink != nil && v.Properties.TemplateLink.URI != nil {
+		u, err := url.Parse(*v.Properties.TemplateLink.URI)
+		if err == nil {
+			// The query holds SAS tokens which change with every deployment.
+			u.RawQuery = ""
+			return name + "/" + u.String()
+		}
+	}
+
+	return name
+}
+
+func deploymentTimestamp(v resources.DeploymentExtended) time.Time {
+	if v.Properties == nil || v.Properties.Timestamp == nil {
+		return time.Time{}
+	}
+
+	return v.Properties.Timestamp.Time
+}
+
+// deploymentFailure returns the error code and resource type of the earliest
+// failed operation of a deployment. The error code falls back to the HTTP
+// status of the operation when the status message holds no error.
+func deploymentFailure(operations []resources.DeploymentOperation) (string, string) {
+	var failed *resources.DeploymentOperationProperties
+	for _, o := range operations {
+		p := o.Properties
+		if p == nil || to.String(p.ProvisioningState) != statusFailed {
+			continue
+		}
+
+		if failed == nil || (p.Timestamp != nil && failed.Timestamp != nil && p.Timestamp.Before(failed.Timestamp.Time)) {
+			failed = p
+		}
+	}
+
+	if failed == nil {
+		return "", ""
+	}
+
+	var resourceType string
+	if failed.TargetResource != nil {
+		resourceType = to.String(failed.TargetResource.ResourceType)
+	}
+
+	errorCode := statusMessageErrorCode(failed.StatusMessage)
+	if errorCode == "" {
+		errorCode = to.String(failed.StatusCode)
+	}
+
+	return errorCode, resourceType
+}
+
+// statusMessageErrorCode returns the error code of the status message of a
+// deployment operation, which is either the error itself or wraps it.
+func statusMessageErrorCode(statusMessage interface{}) string {
+	if statusMessage == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(statusMessage)
+	if err != nil {
+		return ""
+	}
+
+	var message struct {
+		Code  string `json:"code"`
+		Error *struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	err = json.Unmarshal(data, &message)
+	if err != nil {
+		return ""
+	}
+
+	if message.Error != nil && message.Error.Code != "" {
+		return message.Error.Code
+	}
+
+	return message.Code
+}
+
+// parseISO8601Duration parses the duration of a deployment, e.g. "PT1M30.5S".
+func parseISO8601Duration(s string) (time.Duration, bool) {
+	m := iso8601Duration.FindStringSubmatch(s)
+	if m == nil || s == "P" || s == "PT" {
+		return 0, false
+	}
+
+	var seconds float64
+	for i, unit := range []float64{24 * 60 * 60, 60 * 60, 60, 1} {
+		if m[i+1] == "" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, false
+		}
+
+		seconds += v * unit
+	}
+
+	return time.Duration(seconds * float64(time.Second)), true
+}
@@ -0,0 +1,229 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/date"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_latestDeployments(t *testing.T) {
+	now := time.Now()
+	deployment := func(name, templateURI string, timestamp time.Time) resources.DeploymentExtended {
+		v := resources.DeploymentExtended{
+			Name: to.StringPtr(name),
+			Properties: &resources.DeploymentPropertiesExtended{
+				Timestamp: &date.Time{Time: timestamp},
+			},
+		}
+		if templateURI != "" {
+			v.Properties.TemplateLink = &resources.TemplateLink{URI: to.StringPtr(templateURI)}
+		}
+
+		return v
+	}
+
+	deployments := []resources.DeploymentExtended{
+		deployment("masters-1", "https://example.blob.core.windows.net/templates/masters/main.json?sig=a", now.Add(-time.Hour)),
+		deployment("masters-2", "https://example.blob.core.windows.net/templates/masters/main.json?sig=b", now),
+		deployment("workers", "https://example.blob.core.windows.net/templates/workers/main.json?sig=c", now.Add(-2*time.Hour)),
+		deployment("nodepool-abc12", "https://example.blob.core.windows.net/templates/nodepool/main.json?sig=d", now.Add(-time.Hour)),
+		deployment("nodepool-def34", "https://example.blob.core.windows.net/templates/nodepool/main.json?sig=e", now),
+		deployment("nodepool-def34-1", "https://example.blob.core.windows.net/templates/nodepool/main.json?sig=f", now.Add(-time.Hour)),
+		deployment("inline", "", now),
+		{Name: to.StringPtr("no-properties")},
+	}
+
+	var names []string
+	for _, v := range latestDeployments(deployments) {
+		names = append(names, to.String(v.Name))
+	}
+
+	expected := []string{"inline", "masters-2", "no-properties", "nodepool-abc12", "nodepool-def34", "workers"}
+	if !cmp.Equal(names, expected) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, names))
+	}
+}
+
+func Test_deploymentFailure(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name                 string
+		operations           []resources.DeploymentOperation
+		expectedErrorCode    string
+		expectedResourceType string
+	}{
+		{
+			name: "case 0: no failed operation",
+			operations: []resources.DeploymentOperation{
+				{Properties: &resources.DeploymentOperationProperties{ProvisioningState: to.StringPtr(statusSucceeded)}},
+				{},
+			},
+		},
+		{
+			name: "case 1: earliest failed operation with wrapped error",
+			operations: []resources.DeploymentOperation{
+				{
+					Properties: &resources.DeploymentOperationProperties{
+						ProvisioningState: to.StringPtr(statusFailed),
+						Timestamp:         &date.Time{Time: now},
+						StatusCode:        to.StringPtr("Conflict"),
+						StatusMessage:     map[string]interface{}{"status": "Failed", "error": map[string]interface{}{"code": "DeploymentFailed"}},
+						TargetResource:    &resources.TargetResource{ResourceType: to.StringPtr("Microsoft.Resources/deployments")},
+					},
+				},
+				{
+					Properties: &resources.DeploymentOperationProperties{
+						ProvisioningState: to.StringPtr(statusFailed),
+						Timestamp:         &date.Time{Time: now.Add(-time.Minute)},
+						StatusCode:        to.StringPtr("BadRequest"),
+						StatusMessage:     map[string]interface{}{"error": map[string]interface{}{"code": "SkuNotAvailable"}},
+						TargetResource:    &resources.TargetResource{ResourceType: to.StringPtr("Microsoft.Compute/virtualMachineScaleSets")},
+					},
+				},
+			},
+			expectedErrorCode:    "SkuNotAvailable",
+			expectedResourceType: "Microsoft.Compute/virtualMachineScaleSets",
+		},
+		{
+			name: "case 2: status code is used without error in the status message",
+			operations: []resources.DeploymentOperation{
+				{
+					Properties: &resources.DeploymentOperationProperties{
+						ProvisioningState: to.StringPtr(statusFailed),
+						StatusCode:        to.StringPtr("InternalServerError"),
+						StatusMessage:     "something went wrong",
+					},
+				},
+			},
+			expectedErrorCode: "InternalServerError",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			errorCode, resourceType := deploymentFailure(tc.operations)
+			if errorCode != tc.expectedErrorCode {
+				t.Fatalf("error code = %q, want %q", errorCode, tc.expectedErrorCode)
+			}
+			if resourceType != tc.expectedResourceType {
+				t.Fatalf("resource type = %q, want %q", resourceType, tc.expectedResourceType)
+			}
+		})
+	}
+}
+
+func Test_parseISO8601Duration(t *testing.T) {
+	testCases := []struct {
+		name             string
+		input            string
+		expectedDuration time.Duration
+		expectedOK       bool
+	}{
+		{
+			name:             "case 0: minutes and fractional seconds",
+			input:            "PT1M30.5S",
+			expectedDuration: 90*time.Second + 500*time.Millisecond,
+			expectedOK:       true,
+		},
+		{
+			name:             "case 1: days and hours",
+			input:            "P1DT2H",
+			expectedDuration: 26 * time.Hour,
+			expectedOK:       true,
+		},
+		{
+			name:  "case 2: empty duration",
+			input: "",
+		},
+		{
+			name:  "case 3: invalid duration",
+			input: "90s",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			d, ok := parseISO8601Duration(tc.input)
+			if ok != tc.expectedOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.expectedOK)
+			}
+			if d != tc.expectedDuration {
+				t.Fatalf("duration = %v, want %v", d, tc.expectedDuration)
+			}
+		})
+	}
+}
+
+func Test_deploymentStatuses(t *testing.T) {
+	testCases := []struct {
+		name              string
+		deployment        resources.DeploymentExtended
+		expectedState     string
+		expectedStatuses  int
+		expectedLastState string
+	}{
+		{
+			name:              "case 0: missing properties are not specified",
+			deployment:        resources.DeploymentExtended{Name: to.StringPtr("main")},
+			expectedState:     statusNotSpecified,
+			expectedStatuses:  len(knownDeploymentStatuses),
+			expectedLastState: statusWaiting,
+		},
+		{
+			name: "case 1: missing provisioning state is not specified",
+			deployment: resources.DeploymentExtended{
+				Properties: &resources.DeploymentPropertiesExtended{},
+			},
+			expectedState:     statusNotSpecified,
+			expectedStatuses:  len(knownDeploymentStatuses),
+			expectedLastState: statusWaiting,
+		},
+		{
+			name: "case 2: known state",
+			deployment: resources.DeploymentExtended{
+				Properties: &resources.DeploymentPropertiesExtended{ProvisioningState: to.StringPtr(statusAccepted)},
+			},
+			expectedState:     statusAccepted,
+			expectedStatuses:  len(knownDeploymentStatuses),
+			expectedLastState: statusWaiting,
+		},
+		{
+			name: "case 3: unknown state is added",
+			deployment: resources.DeploymentExtended{
+				Properties: &resources.DeploymentPropertiesExtended{ProvisioningState: to.StringPtr("Migrating")},
+			},
+			expectedState:     "Migrating",
+			expectedStatuses:  len(knownDeploymentStatuses) + 1,
+			expectedLastState: "Migrating",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			state := deploymentState(tc.deployment)
+			if state != tc.expectedState {
+				t.Fatalf("state = %q, want %q", state, tc.expectedState)
+			}
+
+			statuses := deploymentStatuses(state)
+			if len(statuses) != tc.expectedStatuses {
+				t.Fatalf("expected %d statuses, got %d", tc.expectedStatuses, len(statuses))
+			}
+			if statuses[len(statuses)-1] != tc.expectedLastState {
+				t.Fatalf("last status = %q, want %q", statuses[len(statuses)-1], tc.expectedLastState)
+			}
+		})
+	}
+}
@@ -81,3 +81,12 @@ func IsNotFound(err error) bool {
 
 	return false
 }
+
+var reloadFailedError = &microerror.Error{
+	Kind: "reloadFailedError",
+}
+
+// IsReloadFailed asserts reloadFailedError.
+func IsReloadFailed(err error) bool {
+	return microerror.Cause(err) == reloadFailedError
+}
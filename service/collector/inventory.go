@@ -0,0 +1,210 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capzv1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/azure-collector/v3/internal/capzcredentials"
+	"github.com/giantswarm/azure-collector/v3/service/collector/key"
+	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+)
+
+var (
+	inventoryClustersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "inventory", "clusters"),
+		"Number of clusters discovered by the collector.",
+		[]string{
+			"kind",
+		},
+		nil,
+	)
+)
+
+type ClusterInventoryConfig struct {
+	CtrlClient client.Client
+	Inventory  *inventory.Inventory
+	Logger     micrologger.Logger
+
+	Location   string
+	GSTenantID string
+}
+
+type ClusterInventory struct {
+	ctrlClient client.Client
+	inventory  *inventory.Inventory
+	logger     micrologger.Logger
+
+	location   string
+	gsTenantID string
+}
+
+// NewClusterInventory discovers the vintage and CAPI clusters of this installation together with the Azure credentials used for them.
+// The result is stored in the inventory so it can be inspected through the inventory endpoint.
+func NewClusterInventory(config ClusterInventoryConfig) (*ClusterInventory, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Location == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Location must not be empty", config)
+	}
+	if config.GSTenantID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
+	}
+
+	i := &ClusterInventory{
+		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
+		logger:     config.Logger,
+		location:   config.Location,
+		gsTenantID: config.GSTenantID,
+	}
+
+	return i, nil
+}
+
+func (i *ClusterInventory) Collect(ch chan<- prometheus.Metric) error {
+	ctx := context.Background()
+
+	vintageClusters, err := i.getVintageClusters(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	capiClusters, err := i.getCAPIClusters(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var clusters []inventory.Cluster
+	clusters = append(clusters, vintageClusters...)
+	clusters = append(clusters, capiClusters...)
+	i.inventory.Sync(clusters)
+
+	ch <- prometheus.MustNewConstMetric(
+		inventoryClustersDesc,
+		prometheus.GaugeValue,
+		float64(len(vintageClusters)),
+		inventory.KindAzureConfig,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		inventoryClustersDesc,
+		prometheus.GaugeValue,
+		float64(len(capiClusters)),
+		inventory.KindCluster,
+	)
+
+	return nil
+}
+
+func (i *ClusterInventory) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- inventoryClustersDesc
+	return nil
+}
+
+func (i *ClusterInventory) getVintageClusters(ctx context.Context) ([]inventory.Cluster, error) {
+	var crs []providerv1alpha1.AzureConfig
+	{
+		mark := ""
+		page := 0
+		for page == 0 || len(mark) > 0 {
+			opts := client.ListOptions{
+				Continue: mark,
+			}
+			list := providerv1alpha1.AzureConfigList{}
+			err := i.ctrlClient.List(ctx, &list, &opts)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			crs = append(crs, list.Items...)
+
+			mark = list.Continue
+			page++
+		}
+	}
+
+	var clusters []inventory.Cluster
+	for _, cr := range crs {
+		cluster := inventory.Cluster{
+			ID:               cr.Name,
+			Namespace:        cr.Namespace,
+			Kind:             inventory.KindAzureConfig,
+			CredentialSource: fmt.Sprintf("Secret %s/%s", key.CredentialNamespace(cr), key.CredentialName(cr)),
+			ResourceGroup:    cr.Name,
+			Location:         i.location,
+		}
+
+		config, err := credential.GetAzureConfigFromSecretName(ctx, i.ctrlClient, key.CredentialName(cr), key.CredentialNamespace(cr), i.gsTenantID)
+		if err != nil {
+			cluster.Error = err.Error()
+		} else {
+			cluster.SubscriptionID = config.SubscriptionID
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
+}
+
+func (i *ClusterInventory) getCAPIClusters(ctx context.Context) ([]inventory.Cluster, error) {
+	list := &capiv1beta1.ClusterList{}
+	err := i.ctrlClient.List(ctx, list, client.InNamespace(metav1.NamespaceAll))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var clusters []inventory.Cluster
+	for _, cr := range list.Items {
+		cluster := inventory.Cluster{
+			ID:            cr.Name,
+			Namespace:     cr.Namespace,
+			Kind:          inventory.KindCluster,
+			ResourceGroup: cr.Name,
+		}
+
+		if cr.Spec.InfrastructureRef != nil {
+			azureCluster := &capzv1beta1.AzureCluster{}
+			err = i.ctrlClient.Get(ctx, client.ObjectKey{Namespace: cr.Spec.InfrastructureRef.Namespace, Name: cr.Spec.InfrastructureRef.Name}, azureCluster)
+			if err != nil {
+				cluster.Error = err.Error()
+			} else {
+				if azureCluster.Spec.ResourceGroup != "" {
+					cluster.ResourceGroup = azureCluster.Spec.ResourceGroup
+				}
+				cluster.Location = azureCluster.Spec.Location
+				cluster.SubscriptionID = azureCluster.Spec.SubscriptionID
+			}
+		}
+
+		azureCredentials, err := capzcredentials.GetAzureCredentialsFromMetadata(ctx, i.ctrlClient, cr.ObjectMeta)
+		if err != nil {
+			cluster.Error = err.Error()
+		} else {
+			cluster.CredentialSource = azureCredentials.Source
+			if cluster.SubscriptionID == "" {
+				cluster.SubscriptionID = azureCredentials.SubscriptionID
+			}
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
+}
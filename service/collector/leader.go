@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// cachedMetricsTTL bounds the staleness of the metrics a standby replica
+	// serves from its latest collection as leader. That way metrics do not
+	// disappear while the leadership moves, but outdated metrics of a former
+	// leader do not stick around.
+	cachedMetricsTTL = 5 * time.Minute
+)
+
+// Leader tells whether this replica is allowed to poll the Azure APIs.
+type Leader interface {
+	IsLeader() bool
+}
+
+// leaderCollector only runs the wrapped collector on the leader. Standby
+// replicas serve the metrics cached during their latest collection as leader
+// until they are older than cachedMetricsTTL.
+type leaderCollector struct {
+	namedCollector
+
+	leader Leader
+
+	mutex    sync.Mutex
+	cached   []prometheus.Metric
+	cachedAt time.Time
+}
+
+func (c *leaderCollector) Collect(ch chan<- prometheus.Metric) error {
+	if !c.leader.IsLeader() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		if time.Since(c.cachedAt) > cachedMetricsTTL {
+			return nil
+		}
+
+		for _, m := range c.cached {
+			ch <- m
+		}
+
+		return nil
+	}
+
+	var collected []prometheus.Metric
+	metrics := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		for m := range metrics {
+			collected = append(collected, m)
+			ch <- m
+		}
+		close(done)
+	}()
+
+	err := c.namedCollector.Collect(metrics)
+	close(metrics)
+	<-done
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	c.mutex.Lock()
+	c.cached = collected
+	c.cachedAt = time.Now()
+	c.mutex.Unlock()
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type fakeLeader struct {
+	leader bool
+}
+
+func (l *fakeLeader) IsLeader() bool {
+	return l.leader
+}
+
+var testLeaderDesc = prometheus.NewDesc("test_leader", "Test metric.", nil, nil)
+
+type metricCollector struct {
+	collections int
+}
+
+func (c *metricCollector) Collect(ch chan<- prometheus.Metric) error {
+	c.collections++
+	ch <- prometheus.MustNewConstMetric(testLeaderDesc, prometheus.GaugeValue, 1)
+	return nil
+}
+
+func (c *metricCollector) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- testLeaderDesc
+	return nil
+}
+
+func (c *metricCollector) Name() string {
+	return "test"
+}
+
+func Test_leaderCollector_Collect(t *testing.T) {
+	leader := &fakeLeader{leader: true}
+	wrapped := &metricCollector{}
+	c := &leaderCollector{
+		namedCollector: wrapped,
+		leader:         leader,
+	}
+
+	collect := func() int {
+		ch := make(chan prometheus.Metric, 10)
+		err := c.Collect(ch)
+		if err != nil {
+			t.Fatalf("expected no error got %#v", err)
+		}
+		close(ch)
+
+		var count int
+		for range ch {
+			count++
+		}
+
+		return count
+	}
+
+	if n := collect(); n != 1 {
+		t.Fatalf("expected the leader to expose 1 metric got %d", n)
+	}
+
+	// A standby serves the metrics of its latest collection as leader.
+	leader.leader = false
+	if n := collect(); n != 1 {
+		t.Fatalf("expected the standby to expose 1 cached metric got %d", n)
+	}
+	if wrapped.collections != 1 {
+		t.Fatalf("expected the standby not to collect, collected %d times", wrapped.collections)
+	}
+
+	// Cached metrics older than the staleness bound are not served.
+	c.cachedAt = time.Now().Add(-cachedMetricsTTL - time.Minute)
+	if n := collect(); n != 0 {
+		t.Fatalf("expected the standby to expose no stale metrics got %d", n)
+	}
+}
@@ -2,16 +2,38 @@ package collector
 
 import (
 	"context"
+	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-11-01/network" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/giantswarm/azure-collector/v3/client"
 	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
+)
+
+const (
+	loadBalancerCollectorName = "load_balancer"
+
+	// loadBalancerNamesAll is the load balancer name selecting all load
+	// balancers in the resource group of every cluster.
+	loadBalancerNamesAll = "*"
+
+	// snatPortsPerFrontendIP is the number of SNAT ports every frontend IP of
+	// an outbound rule provides.
+	snatPortsPerFrontendIP = 64000
 )
 
 var (
+	// defaultLoadBalancerNames are the load balancers collected when no names
+	// are configured.
+	defaultLoadBalancerNames = []string{"kubernetes", "kubernetes-internal"}
+
 	loadBalancerDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(MetricsNamespace, "load_balancer", "backend_pool_instances_count"),
 		"The number of instances behind a backend pool.",
@@ -22,36 +44,126 @@ var (
 		},
 		nil,
 	)
+	loadBalancerFrontendIPsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "load_balancer", "frontend_ip_configurations"),
+		"The number of frontend IP configurations of a load balancer.",
+		[]string{
+			"cluster_id",
+			"load_balancer_name",
+		},
+		nil,
+	)
+	loadBalancerRulesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "load_balancer", "rules"),
+		"The number of load balancing rules of a load balancer.",
+		[]string{
+			"cluster_id",
+			"load_balancer_name",
+		},
+		nil,
+	)
+	loadBalancerProbesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "load_balancer", "probes"),
+		"The number of health probes of a load balancer.",
+		[]string{
+			"cluster_id",
+			"load_balancer_name",
+		},
+		nil,
+	)
+	loadBalancerOutboundRulesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "load_balancer", "outbound_rules"),
+		"The number of outbound rules of a load balancer.",
+		[]string{
+			"cluster_id",
+			"load_balancer_name",
+		},
+		nil,
+	)
+	loadBalancerAllocatedOutboundPortsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "load_balancer", "outbound_rule_allocated_ports"),
+		"The number of SNAT ports allocated to every backend instance by an outbound rule. 0 means the default allocation.",
+		[]string{
+			"cluster_id",
+			"load_balancer_name",
+			"outbound_rule_name",
+		},
+		nil,
+	)
+	loadBalancerSNATPortsCapacityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "load_balancer", "outbound_rule_snat_ports_capacity"),
+		"The number of SNAT ports provided by the frontend IPs of an outbound rule.",
+		[]string{
+			"cluster_id",
+			"load_balancer_name",
+			"outbound_rule_name",
+		},
+		nil,
+	)
+	loadBalancerSNATPortsAllocatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "load_balancer", "outbound_rule_snat_ports_allocated"),
+		"The number of SNAT ports allocated by an outbound rule to all instances of its backend pool. Only exposed for rules with explicitly allocated ports.",
+		[]string{
+			"cluster_id",
+			"load_balancer_name",
+			"outbound_rule_name",
+		},
+		nil,
+	)
 )
 
 type LoadBalancerConfig struct {
-	CtrlClient client.Client
+	CtrlClient ctrlclient.Client
+	Inventory  *inventory.Inventory
 	Logger     micrologger.Logger
+	Shard      sharding.Interface
 	GSTenantID string
+	// Names is optional. It defaults to the kubernetes and
+	// kubernetes-internal load balancers. The name "*" selects all load
+	// balancers in the resource group of every cluster.
+	Names []string
 }
 
 type LoadBalancer struct {
-	ctrlClient client.Client
+	ctrlClient ctrlclient.Client
+	inventory  *inventory.Inventory
 	logger     micrologger.Logger
+	shard      sharding.Interface
 	gsTenantID string
+	names      []string
 }
 
-// NewLoadBalancer exposes metrics about the 'kubernetes' load balancer used to Kubernetes services with type LoadBalancer.
+// NewLoadBalancer exposes metrics about the load balancers of every cluster, e.g. the 'kubernetes' load balancer used to Kubernetes services with type LoadBalancer.
+// Next to the backend pools it exposes the number of frontend IPs, rules and probes and the SNAT port allocation of outbound rules, so that limits and SNAT exhaustion can be alerted on.
 func NewLoadBalancer(config LoadBalancerConfig) (*LoadBalancer, error) {
 	if config.CtrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
 	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
 	if config.GSTenantID == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
 	}
 
+	names := config.Names
+	if len(names) == 0 {
+		names = defaultLoadBalancerNames
+	}
+
 	d := &LoadBalancer{
 		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
 		logger:     config.Logger,
+		shard:      config.Shard,
 		gsTenantID: config.GSTenantID,
+		names:      names,
 	}
 
 	return d, nil
@@ -64,39 +176,202 @@ func (d *LoadBalancer) Collect(ch chan<- prometheus.Metric) error {
 		return microerror.Mask(err)
 	}
 
-	lbNames := []string{"kubernetes", "kubernetes-internal"}
-
 	for clusterID, azureClientSet := range azureClientSets {
-		for _, lbName := range lbNames {
-			lb, err := azureClientSet.LoadBalancersClient.Get(context.Background(), clusterID, lbName, "")
+		if !d.shard.Owns(clusterID) {
+			continue
+		}
+
+		err := d.collectForCluster(ctx, ch, clusterID, azureClientSet)
+		d.inventory.Record(clusterID, loadBalancerCollectorName, err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (d *LoadBalancer) collectForCluster(ctx context.Context, ch chan<- prometheus.Metric, clusterID string, azureClientSet *client.AzureClientSet) error {
+	lbs, err := d.getLoadBalancers(ctx, clusterID, azureClientSet)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, lb := range lbs {
+		if lb.LoadBalancerPropertiesFormat == nil {
+			continue
+		}
+
+		lbName := to.String(lb.Name)
+
+		backendPoolInstances := map[string]int{}
+		if lb.BackendAddressPools != nil {
+			for _, bp := range *lb.BackendAddressPools {
+				if bp.BackendAddressPoolPropertiesFormat != nil && bp.BackendIPConfigurations != nil {
+					backendPoolInstances[strings.ToLower(to.String(bp.ID))] = len(*bp.BackendIPConfigurations)
+
+					ch <- prometheus.MustNewConstMetric(
+						loadBalancerDesc,
+						prometheus.GaugeValue,
+						float64(len(*bp.BackendIPConfigurations)),
+						clusterID,
+						lbName,
+						to.String(bp.Name),
+					)
+				}
+			}
+		}
+
+		counts := []struct {
+			desc  *prometheus.Desc
+			count int
+		}{
+			{desc: loadBalancerFrontendIPsDesc},
+			{desc: loadBalancerRulesDesc},
+			{desc: loadBalancerProbesDesc},
+			{desc: loadBalancerOutboundRulesDesc},
+		}
+		if lb.FrontendIPConfigurations != nil {
+			counts[0].count = len(*lb.FrontendIPConfigurations)
+		}
+		if lb.LoadBalancingRules != nil {
+			counts[1].count = len(*lb.LoadBalancingRules)
+		}
+		if lb.Probes != nil {
+			counts[2].count = len(*lb.Probes)
+		}
+		if lb.OutboundRules != nil {
+			counts[3].count = len(*lb.OutboundRules)
+		}
+
+		for _, c := range counts {
+			ch <- prometheus.MustNewConstMetric(
+				c.desc,
+				prometheus.GaugeValue,
+				float64(c.count),
+				clusterID,
+				lbName,
+			)
+		}
+
+		if lb.OutboundRules != nil {
+			for _, rule := range *lb.OutboundRules {
+				d.collectOutboundRule(ch, clusterID, lbName, rule, backendPoolInstances)
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectOutboundRule exposes the SNAT port allocation of the given outbound
+// rule. backendPoolInstances holds the number of instances of every backend
+// pool keyed by its lower case ID.
+func (d *LoadBalancer) collectOutboundRule(ch chan<- prometheus.Metric, clusterID, lbName string, rule network.OutboundRule, backendPoolInstances map[string]int) {
+	if rule.OutboundRulePropertiesFormat == nil {
+		return
+	}
+
+	var allocatedPorts int32
+	if rule.AllocatedOutboundPorts != nil {
+		allocatedPorts = *rule.AllocatedOutboundPorts
+	}
+
+	var frontendIPs int
+	if rule.FrontendIPConfigurations != nil {
+		frontendIPs = len(*rule.FrontendIPConfigurations)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		loadBalancerAllocatedOutboundPortsDesc,
+		prometheus.GaugeValue,
+		float64(allocatedPorts),
+		clusterID,
+		lbName,
+		to.String(rule.Name),
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		loadBalancerSNATPortsCapacityDesc,
+		prometheus.GaugeValue,
+		float64(frontendIPs*snatPortsPerFrontendIP),
+		clusterID,
+		lbName,
+		to.String(rule.Name),
+	)
+
+	// The default allocation depends on the size of the backend pool and is
+	// not known here.
+	if allocatedPorts > 0 && rule.BackendAddressPool != nil {
+		instances := backendPoolInstances[strings.ToLower(to.String(rule.BackendAddressPool.ID))]
+
+		ch <- prometheus.MustNewConstMetric(
+			loadBalancerSNATPortsAllocatedDesc,
+			prometheus.GaugeValue,
+			float64(int(allocatedPorts)*instances),
+			clusterID,
+			lbName,
+			to.String(rule.Name),
+		)
+	}
+}
+
+// getLoadBalancers returns the configured load balancers of the given cluster,
+// or all load balancers in its resource group when "*" is configured.
+func (d *LoadBalancer) getLoadBalancers(ctx context.Context, clusterID string, azureClientSet *client.AzureClientSet) ([]network.LoadBalancer, error) {
+	var lbs []network.LoadBalancer
+
+	var all bool
+	for _, lbName := range d.names {
+		if lbName == loadBalancerNamesAll {
+			all = true
+		}
+	}
+
+	if !all {
+		for _, lbName := range d.names {
+			lb, err := azureClientSet.LoadBalancersClient.Get(ctx, clusterID, lbName, "")
 			if IsNotFound(err) {
 				// Load balancer might be missing, all good.
 				continue
 			} else if err != nil {
-				return microerror.Mask(err)
+				return nil, microerror.Mask(err)
 			}
 
-			if lb.BackendAddressPools != nil {
-				for _, bp := range *lb.BackendAddressPools {
-					if bp.BackendIPConfigurations != nil {
-						ch <- prometheus.MustNewConstMetric(
-							loadBalancerDesc,
-							prometheus.GaugeValue,
-							float64(len(*bp.BackendIPConfigurations)),
-							clusterID,
-							lbName,
-							*bp.Name,
-						)
-					}
-				}
-			}
+			lbs = append(lbs, lb)
+		}
+
+		return lbs, nil
+	}
+
+	r, err := azureClientSet.LoadBalancersClient.ListComplete(ctx, clusterID)
+	if IsNotFound(err) {
+		// Resource group might be missing, all good.
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for r.NotDone() {
+		lbs = append(lbs, r.Value())
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
 		}
 	}
 
-	return nil
+	return lbs, nil
 }
 
 func (d *LoadBalancer) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- loadBalancerDesc
+	ch <- loadBalancerFrontendIPsDesc
+	ch <- loadBalancerRulesDesc
+	ch <- loadBalancerProbesDesc
+	ch <- loadBalancerOutboundRulesDesc
+	ch <- loadBalancerAllocatedOutboundPortsDesc
+	ch <- loadBalancerSNATPortsCapacityDesc
+	ch <- loadBalancerSNATPortsAllocatedDesc
 	return nil
 }
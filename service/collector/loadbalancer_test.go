@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-11-01/network" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_LoadBalancer_collectOutboundRule(t *testing.T) {
+	backendPoolInstances := map[string]int{
+		"/subscriptions/s/resourcegroups/abc12/providers/microsoft.network/loadbalancers/kubernetes/backendaddresspools/workers": 3,
+	}
+
+	testCases := []struct {
+		name           string
+		rule           network.OutboundRule
+		expectedValues map[string][]float64
+	}{
+		{
+			name: "case 0: explicitly allocated ports",
+			rule: network.OutboundRule{
+				Name: to.StringPtr("outbound"),
+				OutboundRulePropertiesFormat: &network.OutboundRulePropertiesFormat{
+					AllocatedOutboundPorts:   to.Int32Ptr(1024),
+					FrontendIPConfigurations: &[]network.SubResource{{}, {}},
+					BackendAddressPool: &network.SubResource{
+						ID: to.StringPtr("/subscriptions/s/resourceGroups/abc12/providers/Microsoft.Network/loadBalancers/kubernetes/backendAddressPools/workers"),
+					},
+				},
+			},
+			expectedValues: map[string][]float64{
+				"outbound_rule_allocated_ports":      {1024},
+				"outbound_rule_snat_ports_capacity":  {128000},
+				"outbound_rule_snat_ports_allocated": {3072},
+			},
+		},
+		{
+			name: "case 1: default allocation",
+			rule: network.OutboundRule{
+				Name: to.StringPtr("outbound"),
+				OutboundRulePropertiesFormat: &network.OutboundRulePropertiesFormat{
+					FrontendIPConfigurations: &[]network.SubResource{{}},
+				},
+			},
+			expectedValues: map[string][]float64{
+				"outbound_rule_allocated_ports":     {0},
+				"outbound_rule_snat_ports_capacity": {64000},
+			},
+		},
+		{
+			name: "case 2: missing properties",
+			rule: network.OutboundRule{
+				Name: to.StringPtr("outbound"),
+			},
+			expectedValues: map[string][]float64{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			ch := make(chan prometheus.Metric, 10)
+			d := &LoadBalancer{}
+			d.collectOutboundRule(ch, "abc12", "kubernetes", tc.rule, backendPoolInstances)
+			close(ch)
+
+			// All emitted metrics are counted by their description, so that
+			// unexpected or repeated metrics fail the test.
+			values := map[string][]float64{}
+			for m := range ch {
+				var metric dto.Metric
+				err := m.Write(&metric)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				name, ok := loadBalancerMetricNames()[m.Desc().String()]
+				if !ok {
+					t.Fatalf("unexpected metric %s", m.Desc().String())
+				}
+				values[name] = append(values[name], metric.GetGauge().GetValue())
+			}
+
+			if !cmp.Equal(values, tc.expectedValues) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedValues, values))
+			}
+		})
+	}
+}
+
+// loadBalancerMetricNames maps the descriptions of the outbound rule metrics
+// to their names.
+func loadBalancerMetricNames() map[string]string {
+	return map[string]string{
+		loadBalancerAllocatedOutboundPortsDesc.String(): "outbound_rule_allocated_ports",
+		loadBalancerSNATPortsCapacityDesc.String():      "outbound_rule_snat_ports_capacity",
+		loadBalancerSNATPortsAllocatedDesc.String():     "outbound_rule_snat_ports_allocated",
+	}
+}
@@ -0,0 +1,439 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/compute/mgmt/compute"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-11-01/network" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	azureclient "github.com/giantswarm/azure-collector/v3/client"
+	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
+)
+
+const (
+	orphanedResourcesCollectorName = "orphaned_resources"
+	orphanedSubsystem              = "orphaned"
+
+	orphanedTypeDisk             = "disk"
+	orphanedTypeNetworkInterface = "network_interface"
+	orphanedTypePublicIP         = "public_ip"
+
+	labelSKU = "sku"
+
+	// createdTimeFilter selects the resources whose creation time is not part
+	// of their own API and has to be looked up via ARM.
+	createdTimeFilter = "resourceType eq 'Microsoft.Network/networkInterfaces' or resourceType eq 'Microsoft.Network/publicIPAddresses'"
+)
+
+var (
+	orphanedResourceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, orphanedSubsystem, "resource"),
+		"Unattached managed disk, network interface or public IP. The value is always 1.",
+		[]string{
+			labelSubscriptionId,
+			labelResourceGroup,
+			labelType,
+			labelName,
+			labelLocation,
+			labelSKU,
+			labelClusterID,
+		},
+		nil,
+	)
+	orphanedResourceAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, orphanedSubsystem, "resource_age_seconds"),
+		"Age in seconds of an unattached managed disk, network interface or public IP.",
+		[]string{
+			labelSubscriptionId,
+			labelResourceGroup,
+			labelType,
+			labelName,
+		},
+		nil,
+	)
+	orphanedDiskSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, orphanedSubsystem, "disk_size_bytes"),
+		"Size in bytes of an unattached managed disk.",
+		[]string{
+			labelSubscriptionId,
+			labelResourceGroup,
+			labelName,
+		},
+		nil,
+	)
+)
+
+type OrphanedResourcesConfig struct {
+	CtrlClient client.Client
+	Inventory  *inventory.Inventory
+	Logger     micrologger.Logger
+	Shard      sharding.Interface
+	GSTenantID string
+}
+
+type OrphanedResources struct {
+	ctrlClient client.Client
+	inventory  *inventory.Inventory
+	logger     micrologger.Logger
+	shard      sharding.Interface
+	gsTenantID string
+}
+
+// NewOrphanedResources exposes metrics on the managed disks, network interfaces and public IPs of every subscription which are not attached to anything.
+// Deleted clusters and failed VMSS scale-ins leave them behind and they cost money.
+func NewOrphanedResources(config OrphanedResourcesConfig) (*OrphanedResources, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
+	if config.GSTenantID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
+	}
+
+	o := &OrphanedResources{
+		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
+		logger:     config.Logger,
+		shard:      config.Shard,
+		gsTenantID: config.GSTenantID,
+	}
+
+	return o, nil
+}
+
+// orphanedResource is an unattached managed disk, network interface or public
+// IP.
+type orphanedResource struct {
+	ID        string
+	Type      string
+	Name      string
+	Location  string
+	SKU       string
+	ClusterID string
+	// Created is zero when the creation time is unknown.
+	Created time.Time
+	// SizeBytes is only set for disks.
+	SizeBytes int64
+}
+
+func (o *OrphanedResources) Collect(ch chan<- prometheus.Metric) error {
+	ctx := context.Background()
+	clientSets, err := credential.GetAzureClientSetsFromCredentialSecretsBySubscription(ctx, o.ctrlClient, o.gsTenantID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var g errgroup.Group
+
+	for subscriptionID, item := range clientSets {
+		if !o.shard.Owns(subscriptionID) {
+			continue
+		}
+
+		subscriptionID := subscriptionID
+		clientSet := item
+
+		g.Go(func() error {
+			err := o.collectForClientSet(ctx, ch, subscriptionID, clientSet)
+			o.inventory.RecordSubscription(subscriptionID, orphanedResourcesCollectorName, err)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (o *OrphanedResources) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- orphanedResourceDesc
+	ch <- orphanedResourceAgeDesc
+	ch <- orphanedDiskSizeDesc
+
+	return nil
+}
+
+func (o *OrphanedResources) collectForClientSet(ctx context.Context, ch chan<- prometheus.Metric, subscriptionID string, clientSet *azureclient.AzureClientSet) error {
+	var resources []orphanedResource
+
+	{
+		var disks []compute.Disk
+
+		r, err := clientSet.DisksClient.ListComplete(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for r.NotDone() {
+			disks = append(disks, r.Value())
+
+			err := r.NextWithContext(ctx)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		resources = append(resources, orphanedDisks(disks)...)
+	}
+
+	{
+		var nics []network.Interface
+
+		r, err := clientSet.InterfacesClient.ListAllComplete(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for r.NotDone() {
+			nics = append(nics, r.Value())
+
+			err := r.NextWithContext(ctx)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		resources = append(resources, orphanedInterfaces(nics)...)
+	}
+
+	{
+		// Public IPs of NAT gateways have no IP configuration, so they
+		// are looked up in order to not report them.
+		natGatewayIPs := map[string]bool{}
+		{
+			r, err := clientSet.NatGatewaysClient.ListAllComplete(ctx)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for r.NotDone() {
+				natGateway := r.Value()
+				if natGateway.NatGatewayPropertiesFormat != nil && natGateway.PublicIPAddresses != nil {
+					for _, ip := range *natGateway.PublicIPAddresses {
+						natGatewayIPs[strings.ToLower(to.String(ip.ID))] = true
+					}
+				}
+
+				err := r.NextWithContext(ctx)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+		}
+
+		var ips []network.PublicIPAddress
+
+		r, err := clientSet.PublicIPAddressesClient.ListAllComplete(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for r.NotDone() {
+			ips = append(ips, r.Value())
+
+			err := r.NextWithContext(ctx)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		resources = append(resources, orphanedPublicIPs(ips, natGatewayIPs)...)
+	}
+
+	if len(resources) == 0 {
+		return nil
+	}
+
+	// Network interfaces and public IPs expose no creation time, so it is
+	// taken from ARM. Failing to do so only omits their age.
+	created, err := getCreatedTimes(ctx, clientSet)
+	if err != nil {
+		o.logger.Errorf(ctx, err, "Unable to get creation time of network resources in subscription %q", subscriptionID)
+	}
+
+	now := time.Now()
+
+	for _, r := range resources {
+		resourceGroup := ""
+		if id, err := azure.ParseResourceID(r.ID); err == nil {
+			resourceGroup = id.ResourceGroup
+		}
+
+		if r.Created.IsZero() {
+			r.Created = created[strings.ToLower(r.ID)]
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			orphanedResourceDesc,
+			prometheus.GaugeValue,
+			gaugeValue,
+			subscriptionID,
+			resourceGroup,
+			r.Type,
+			r.Name,
+			r.Location,
+			r.SKU,
+			r.ClusterID,
+		)
+
+		if !r.Created.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				orphanedResourceAgeDesc,
+				prometheus.GaugeValue,
+				now.Sub(r.Created).Seconds(),
+				subscriptionID,
+				resourceGroup,
+				r.Type,
+				r.Name,
+			)
+		}
+
+		if r.Type == orphanedTypeDisk {
+			ch <- prometheus.MustNewConstMetric(
+				orphanedDiskSizeDesc,
+				prometheus.GaugeValue,
+				float64(r.SizeBytes),
+				subscriptionID,
+				resourceGroup,
+				r.Name,
+			)
+		}
+	}
+
+	return nil
+}
+
+// getCreatedTimes returns the creation time of the network interfaces and
+// public IPs of the subscription keyed by their lower case ID.
+func getCreatedTimes(ctx context.Context, clientSet *azureclient.AzureClientSet) (map[string]time.Time, error) {
+	created := map[string]time.Time{}
+
+	r, err := clientSet.ResourcesClient.ListComplete(ctx, createdTimeFilter, "createdTime", nil)
+	if err != nil {
+		return created, microerror.Mask(err)
+	}
+
+	for r.NotDone() {
+		v := r.Value()
+		if v.CreatedTime != nil {
+			created[strings.ToLower(to.String(v.ID))] = v.CreatedTime.Time
+		}
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			return created, microerror.Mask(err)
+		}
+	}
+
+	return created, nil
+}
+
+// orphanedDisks returns the managed disks which are not attached to a VM.
+func orphanedDisks(disks []compute.Disk) []orphanedResource {
+	var orphaned []orphanedResource
+	for _, d := range disks {
+		if d.DiskProperties == nil || d.DiskState != compute.Unattached || d.ManagedBy != nil {
+			continue
+		}
+
+		r := orphanedResource{
+			ID:       to.String(d.ID),
+			Type:     orphanedTypeDisk,
+			Name:     to.String(d.Name),
+			Location: to.String(d.Location),
+		}
+		r.ClusterID, _ = owningCluster(d.Tags)
+
+		if d.Sku != nil {
+			r.SKU = string(d.Sku.Name)
+		}
+		if d.TimeCreated != nil {
+			r.Created = d.TimeCreated.Time
+		}
+		if d.DiskSizeBytes != nil {
+			r.SizeBytes = *d.DiskSizeBytes
+		} else if d.DiskSizeGB != nil {
+			r.SizeBytes = int64(*d.DiskSizeGB) << 30
+		}
+
+		orphaned = append(orphaned, r)
+	}
+
+	return orphaned
+}
+
+// orphanedInterfaces returns the network interfaces which are neither attached
+// to a VM nor linked to a private endpoint. Network interfaces of VMSS
+// instances are not listed by the API in the first place.
+func orphanedInterfaces(nics []network.Interface) []orphanedResource {
+	var orphaned []orphanedResource
+	for _, n := range nics {
+		if n.InterfacePropertiesFormat == nil || n.VirtualMachine != nil || n.PrivateEndpoint != nil {
+			continue
+		}
+
+		r := orphanedResource{
+			ID:       to.String(n.ID),
+			Type:     orphanedTypeNetworkInterface,
+			Name:     to.String(n.Name),
+			Location: to.String(n.Location),
+		}
+		r.ClusterID, _ = owningCluster(n.Tags)
+
+		orphaned = append(orphaned, r)
+	}
+
+	return orphaned
+}
+
+// orphanedPublicIPs returns the public IPs which have no IP configuration and
+// are not used by a NAT gateway. natGatewayIPs is keyed by lower case ID.
+func orphanedPublicIPs(ips []network.PublicIPAddress, natGatewayIPs map[string]bool) []orphanedResource {
+	var orphaned []orphanedResource
+	for _, ip := range ips {
+		if ip.PublicIPAddressPropertiesFormat == nil || ip.IPConfiguration != nil || natGatewayIPs[strings.ToLower(to.String(ip.ID))] {
+			continue
+		}
+
+		r := orphanedResource{
+			ID:       to.String(ip.ID),
+			Type:     orphanedTypePublicIP,
+			Name:     to.String(ip.Name),
+			Location: to.String(ip.Location),
+		}
+		r.ClusterID, _ = owningCluster(ip.Tags)
+
+		if ip.Sku != nil {
+			r.SKU = string(ip.Sku.Name)
+		}
+
+		orphaned = append(orphaned, r)
+	}
+
+	return orphaned
+}
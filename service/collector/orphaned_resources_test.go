@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/compute/mgmt/compute"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-11-01/network" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_orphanedResources(t *testing.T) {
+	testCases := []struct {
+		name          string
+		disks         []compute.Disk
+		nics          []network.Interface
+		ips           []network.PublicIPAddress
+		natGatewayIPs map[string]bool
+		expected      []orphanedResource
+	}{
+		{
+			name: "case 0: attached resources are not orphaned",
+			disks: []compute.Disk{
+				{
+					Name:           to.StringPtr("attached"),
+					DiskProperties: &compute.DiskProperties{DiskState: compute.Attached},
+				},
+				{
+					Name:           to.StringPtr("reserved"),
+					ManagedBy:      to.StringPtr("/subscriptions/s/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"),
+					DiskProperties: &compute.DiskProperties{DiskState: compute.Unattached},
+				},
+			},
+			nics: []network.Interface{
+				{
+					Name:                      to.StringPtr("vm-nic"),
+					InterfacePropertiesFormat: &network.InterfacePropertiesFormat{VirtualMachine: &network.SubResource{ID: to.StringPtr("vm")}},
+				},
+				{
+					Name:                      to.StringPtr("pe-nic"),
+					InterfacePropertiesFormat: &network.InterfacePropertiesFormat{PrivateEndpoint: &network.PrivateEndpoint{}},
+				},
+			},
+			ips: []network.PublicIPAddress{
+				{
+					Name:                            to.StringPtr("lb-ip"),
+					PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{IPConfiguration: &network.IPConfiguration{}},
+				},
+				{
+					ID:                              to.StringPtr("/subscriptions/s/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/NAT-IP"),
+					Name:                            to.StringPtr("nat-ip"),
+					PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{},
+				},
+			},
+			natGatewayIPs: map[string]bool{
+				"/subscriptions/s/resourcegroups/rg/providers/microsoft.network/publicipaddresses/nat-ip": true,
+			},
+		},
+		{
+			name: "case 1: unattached resources are orphaned with their owning cluster",
+			disks: []compute.Disk{
+				{
+					ID:       to.StringPtr("disk-id"),
+					Name:     to.StringPtr("disk"),
+					Location: to.StringPtr("westeurope"),
+					Sku:      &compute.DiskSku{Name: compute.PremiumLRS},
+					Tags:     map[string]*string{clusterTag: to.StringPtr("abc12")},
+					DiskProperties: &compute.DiskProperties{
+						DiskState:  compute.Unattached,
+						DiskSizeGB: to.Int32Ptr(2),
+					},
+				},
+			},
+			nics: []network.Interface{
+				{
+					ID:                        to.StringPtr("nic-id"),
+					Name:                      to.StringPtr("nic"),
+					Location:                  to.StringPtr("westeurope"),
+					Tags:                      map[string]*string{"sigs.k8s.io_cluster-api-provider-azure_cluster_def34": to.StringPtr("owned")},
+					InterfacePropertiesFormat: &network.InterfacePropertiesFormat{},
+				},
+			},
+			ips: []network.PublicIPAddress{
+				{
+					ID:                              to.StringPtr("ip-id"),
+					Name:                            to.StringPtr("ip"),
+					Location:                        to.StringPtr("westeurope"),
+					Sku:                             &network.PublicIPAddressSku{Name: network.PublicIPAddressSkuNameStandard},
+					PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{},
+				},
+			},
+			expected: []orphanedResource{
+				{ID: "disk-id", Type: orphanedTypeDisk, Name: "disk", Location: "westeurope", SKU: "Premium_LRS", ClusterID: "abc12", SizeBytes: 2 << 30},
+				{ID: "nic-id", Type: orphanedTypeNetworkInterface, Name: "nic", Location: "westeurope", ClusterID: "def34"},
+				{ID: "ip-id", Type: orphanedTypePublicIP, Name: "ip", Location: "westeurope", SKU: "Standard"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var orphaned []orphanedResource
+			orphaned = append(orphaned, orphanedDisks(tc.disks)...)
+			orphaned = append(orphaned, orphanedInterfaces(tc.nics)...)
+			orphaned = append(orphaned, orphanedPublicIPs(tc.ips, tc.natGatewayIPs)...)
+
+			if !cmp.Equal(orphaned, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, orphaned))
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-11-01/network"          //nolint:staticcheck
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2019-06-01/insights" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/azure-collector/v3/client"
+	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
+)
+
+const (
+	platformMetricsCollectorName = "platform_metrics"
+
+	// platformMetricsTimespan is how far back Azure Monitor is queried. The
+	// latest complete data point within it is exposed.
+	platformMetricsTimespan = 5 * time.Minute
+	platformMetricsInterval = "PT1M"
+)
+
+// platformMetric maps an Azure Monitor metric to the Prometheus metric it is
+// exposed as.
+type platformMetric struct {
+	Name        string
+	Aggregation insights.AggregationType
+	Desc        *prometheus.Desc
+}
+
+var (
+	loadBalancerPlatformMetrics = []platformMetric{
+		{
+			Name:        "VipAvailability",
+			Aggregation: insights.Average,
+			Desc: prometheus.NewDesc(
+				prometheus.BuildFQName(MetricsNamespace, "load_balancer", "vip_availability_percent"),
+				"Data path availability of the frontends of a load balancer as reported by Azure Monitor.",
+				[]string{
+					"cluster_id",
+					"load_balancer_name",
+				},
+				nil,
+			),
+		},
+		{
+			Name:        "DipAvailability",
+			Aggregation: insights.Average,
+			Desc: prometheus.NewDesc(
+				prometheus.BuildFQName(MetricsNamespace, "load_balancer", "dip_availability_percent"),
+				"Health probe status of the backends of a load balancer as reported by Azure Monitor.",
+				[]string{
+					"cluster_id",
+					"load_balancer_name",
+				},
+				nil,
+			),
+		},
+		{
+			Name:        "SnatConnectionCount",
+			Aggregation: insights.Total,
+			Desc: prometheus.NewDesc(
+				prometheus.BuildFQName(MetricsNamespace, "load_balancer", "snat_connections"),
+				"Number of SNAT connections of a load balancer created within a minute as reported by Azure Monitor.",
+				[]string{
+					"cluster_id",
+					"load_balancer_name",
+				},
+				nil,
+			),
+		},
+		{
+			Name:        "UsedSnatPorts",
+			Aggregation: insights.Average,
+			Desc: prometheus.NewDesc(
+				prometheus.BuildFQName(MetricsNamespace, "load_balancer", "used_snat_ports"),
+				"Number of SNAT ports of a load balancer in use as reported by Azure Monitor.",
+				[]string{
+					"cluster_id",
+					"load_balancer_name",
+				},
+				nil,
+			),
+		},
+	}
+
+	natGatewayPlatformMetrics = []platformMetric{
+		{
+			Name:        "SNATConnectionCount",
+			Aggregation: insights.Total,
+			Desc: prometheus.NewDesc(
+				prometheus.BuildFQName(MetricsNamespace, "nat_gateway", "snat_connections"),
+				"Number of SNAT connections of a NAT gateway created within a minute as reported by Azure Monitor.",
+				[]string{
+					"cluster_id",
+					"nat_gateway_name",
+				},
+				nil,
+			),
+		},
+		{
+			Name:        "DroppedPackets",
+			Aggregation: insights.Total,
+			Desc: prometheus.NewDesc(
+				prometheus.BuildFQName(MetricsNamespace, "nat_gateway", "dropped_packets"),
+				"Number of packets dropped by a NAT gateway within a minute as reported by Azure Monitor.",
+				[]string{
+					"cluster_id",
+					"nat_gateway_name",
+				},
+				nil,
+			),
+		},
+	}
+)
+
+type PlatformMetricsConfig struct {
+	CtrlClient ctrlclient.Client
+	Inventory  *inventory.Inventory
+	Logger     micrologger.Logger
+	Shard      sharding.Interface
+	GSTenantID string
+}
+
+type PlatformMetrics struct {
+	ctrlClient ctrlclient.Client
+	inventory  *inventory.Inventory
+	logger     micrologger.Logger
+	shard      sharding.Interface
+	gsTenantID string
+}
+
+// NewPlatformMetrics re-exposes the Azure Monitor platform metrics of the load balancers and NAT gateways in the resource group of every cluster.
+// They tell whether the data path is healthy, which the backend pools of the load balancers do not.
+// Basic load balancers are skipped because Azure Monitor has no metrics for them.
+func NewPlatformMetrics(config PlatformMetricsConfig) (*PlatformMetrics, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
+	if config.GSTenantID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
+	}
+
+	p := &PlatformMetrics{
+		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
+		logger:     config.Logger,
+		shard:      config.Shard,
+		gsTenantID: config.GSTenantID,
+	}
+
+	return p, nil
+}
+
+func (p *PlatformMetrics) Collect(ch chan<- prometheus.Metric) error {
+	ctx := context.Background()
+	azureClientSets, err := credential.GetAzureClientSetsByCluster(ctx, p.ctrlClient, p.gsTenantID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for clusterID, azureClientSet := range azureClientSets {
+		if !p.shard.Owns(clusterID) {
+			continue
+		}
+
+		err := p.collectForCluster(ctx, ch, clusterID, azureClientSet)
+		p.inventory.Record(clusterID, platformMetricsCollectorName, err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (p *PlatformMetrics) Describe(ch chan<- *prometheus.Desc) error {
+	for _, m := range loadBalancerPlatformMetrics {
+		ch <- m.Desc
+	}
+	for _, m := range natGatewayPlatformMetrics {
+		ch <- m.Desc
+	}
+
+	return nil
+}
+
+func (p *PlatformMetrics) collectForCluster(ctx context.Context, ch chan<- prometheus.Metric, clusterID string, azureClientSet *client.AzureClientSet) error {
+	{
+		r, err := azureClientSet.LoadBalancersClient.ListComplete(ctx, clusterID)
+		if IsNotFound(err) {
+			// Resource group might be missing, all good.
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for r.NotDone() {
+			lb := r.Value()
+			if lb.Sku != nil && lb.Sku.Name == network.LoadBalancerSkuNameStandard {
+				p.collectForResource(ctx, ch, azureClientSet.MetricsClient, to.String(lb.ID), loadBalancerPlatformMetrics, clusterID, to.String(lb.Name))
+			}
+
+			err := r.NextWithContext(ctx)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		r, err := azureClientSet.NatGatewaysClient.ListComplete(ctx, clusterID)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for r.NotDone() {
+			natGateway := r.Value()
+			p.collectForResource(ctx, ch, azureClientSet.MetricsClient, to.String(natGateway.ID), natGatewayPlatformMetrics, clusterID, to.String(natGateway.Name))
+
+			err := r.NextWithContext(ctx)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectForResource queries the given platform metrics of a resource with a
+// single request and exposes their latest values with the given label values.
+// Failures are logged, so that one resource does not hide the others.
+func (p *PlatformMetrics) collectForResource(ctx context.Context, ch chan<- prometheus.Metric, metricsClient *insights.MetricsClient, resourceID string, metrics []platformMetric, labelValues ...string) {
+	var names []string
+	aggregations := map[insights.AggregationType]bool{}
+	for _, m := range metrics {
+		names = append(names, m.Name)
+		aggregations[m.Aggregation] = true
+	}
+
+	var aggregation []string
+	for _, a := range []insights.AggregationType{insights.Average, insights.Total} {
+		if aggregations[a] {
+			aggregation = append(aggregation, string(a))
+		}
+	}
+
+	end := time.Now().UTC()
+	timespan := fmt.Sprintf("%s/%s", end.Add(-platformMetricsTimespan).Format(time.RFC3339), end.Format(time.RFC3339))
+
+	resp, err := metricsClient.List(ctx, resourceID, timespan, to.StringPtr(platformMetricsInterval), strings.Join(names, ","), strings.Join(aggregation, ","), nil, "", "", insights.Data, "")
+	if err != nil {
+		p.logger.Errorf(ctx, err, "Unable to get platform metrics of %q", resourceID)
+		return
+	}
+
+	if resp.Value == nil {
+		return
+	}
+
+	for _, m := range metrics {
+		for _, v := range *resp.Value {
+			if v.Name == nil || !strings.EqualFold(to.String(v.Name.Value), m.Name) {
+				continue
+			}
+
+			value, ok := latestValue(v, m.Aggregation)
+			if !ok {
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				m.Desc,
+				prometheus.GaugeValue,
+				value,
+				labelValues...,
+			)
+		}
+	}
+}
+
+// latestValue returns the value of the given aggregation of the latest data
+// point of the metric. Azure Monitor returns data points without values for
+// the current minute, which are skipped.
+func latestValue(metric insights.Metric, aggregation insights.AggregationType) (float64, bool) {
+	if metric.Timeseries == nil {
+		return 0, false
+	}
+
+	var latest time.Time
+	var value float64
+	var found bool
+	for _, ts := range *metric.Timeseries {
+		if ts.Data == nil {
+			continue
+		}
+
+		for _, d := range *ts.Data {
+			var v *float64
+			switch aggregation {
+			case insights.Average:
+				v = d.Average
+			case insights.Total:
+				v = d.Total
+			}
+			if v == nil || d.TimeStamp == nil {
+				continue
+			}
+
+			if !found || d.TimeStamp.After(latest) {
+				latest = d.TimeStamp.Time
+				value = *v
+				found = true
+			}
+		}
+	}
+
+	return value, found
+}
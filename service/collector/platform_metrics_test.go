@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2019-06-01/insights" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/date"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func Test_latestValue(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name          string
+		metric        insights.Metric
+		aggregation   insights.AggregationType
+		expectedValue float64
+		expectedOK    bool
+	}{
+		{
+			name:        "case 0: metric without time series",
+			metric:      insights.Metric{},
+			aggregation: insights.Average,
+		},
+		{
+			name: "case 1: latest data point with a value is used",
+			metric: insights.Metric{
+				Timeseries: &[]insights.TimeSeriesElement{
+					{
+						Data: &[]insights.MetricValue{
+							{TimeStamp: &date.Time{Time: now.Add(-2 * time.Minute)}, Average: to.Float64Ptr(99.5)},
+							{TimeStamp: &date.Time{Time: now.Add(-time.Minute)}, Average: to.Float64Ptr(100)},
+							{TimeStamp: &date.Time{Time: now}},
+						},
+					},
+				},
+			},
+			aggregation:   insights.Average,
+			expectedValue: 100,
+			expectedOK:    true,
+		},
+		{
+			name: "case 2: other aggregations are ignored",
+			metric: insights.Metric{
+				Timeseries: &[]insights.TimeSeriesElement{
+					{
+						Data: &[]insights.MetricValue{
+							{TimeStamp: &date.Time{Time: now}, Average: to.Float64Ptr(3)},
+						},
+					},
+				},
+			},
+			aggregation: insights.Total,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			value, ok := latestValue(tc.metric, tc.aggregation)
+			if ok != tc.expectedOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.expectedOK)
+			}
+			if value != tc.expectedValue {
+				t.Fatalf("value = %v, want %v", value, tc.expectedValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/exporterkit/collector"
+	"github.com/giantswarm/microendpoint/service/healthz"
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// collectionStuckThreshold is the duration after which a collection which
+	// has not finished yet is considered to be stuck.
+	collectionStuckThreshold = 10 * time.Minute
+	// bootCollectionRetryInterval is how long Boot waits before running the
+	// initial collection again when it did not make the replica ready.
+	bootCollectionRetryInterval = 30 * time.Second
+)
+
+// namedCollector is a collector which knows the name it is identified by in
+// the probes.
+type namedCollector interface {
+	collector.Interface
+	Name() string
+}
+
+// collectionState tracks the collections of all collectors in a set.
+type collectionState struct {
+	mutex       sync.Mutex
+	nextID      uint64
+	running     map[uint64]collection
+	lastSuccess time.Time
+}
+
+type collection struct {
+	name    string
+	started time.Time
+}
+
+func newCollectionState() *collectionState {
+	return &collectionState{
+		mutex:   sync.Mutex{},
+		running: map[uint64]collection{},
+	}
+}
+
+func (s *collectionState) start(name string) uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nextID++
+	s.running[s.nextID] = collection{
+		name:    name,
+		started: time.Now(),
+	}
+
+	return s.nextID
+}
+
+func (s *collectionState) finish(id uint64, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.running, id)
+	if err == nil {
+		s.lastSuccess = time.Now()
+	}
+}
+
+// stuck returns the name of a collector whose collection has been running for
+// longer than the given threshold.
+func (s *collectionState) stuck(threshold time.Duration) (string, time.Duration, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, c := range s.running {
+		d := time.Since(c.started)
+		if d > threshold {
+			return c.name, d, true
+		}
+	}
+
+	return "", 0, false
+}
+
+func (s *collectionState) succeededAt() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.lastSuccess
+}
+
+// instrumentedCollector records the collections of the wrapped collector in
+// the collection state of the set.
+type instrumentedCollector struct {
+	collector.Interface
+
+	name  string
+	state *collectionState
+}
+
+func (c *instrumentedCollector) Collect(ch chan<- prometheus.Metric) error {
+	id := c.state.start(c.name)
+	err := c.Interface.Collect(ch)
+	c.state.finish(id, err)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Probe implements the microendpoint healthz service based on the state of the
+// collector set.
+type Probe struct {
+	description string
+	name        string
+	check       func() error
+}
+
+func (p *Probe) GetHealthz(ctx context.Context) (healthz.Response, error) {
+	response := healthz.Response{
+		Description: p.description,
+		Name:        p.name,
+	}
+
+	err := p.check()
+	if err != nil {
+		response.Failed = true
+		response.Message = err.Error()
+	}
+
+	return response, nil
+}
+
+// Boot registers the set and runs the initial collection itself. Prometheus
+// only scrapes ready replicas, so readiness must not depend on being scraped.
+// The initial collection is retried until it made the replica ready.
+func (s *Set) Boot(ctx context.Context) error {
+	err := s.Set.Boot(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for {
+		s.collectOnce()
+
+		err = s.ready()
+		if err == nil {
+			return nil
+		}
+
+		s.logger.Debugf(ctx, "replica not ready after initial collection: %s", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(bootCollectionRetryInterval):
+		}
+	}
+}
+
+// collectOnce runs all collectors of the set and discards their metrics.
+func (s *Set) collectOnce() {
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+
+	s.Set.Collect(ch)
+	close(ch)
+	<-done
+}
+
+func (s *Set) ready() error {
+	if s.inventory.SyncedAt().IsZero() {
+		return fmt.Errorf("clusters have not been discovered yet")
+	}
+	if s.state.succeededAt().IsZero() {
+		return fmt.Errorf("no collection succeeded yet")
+	}
+
+	return nil
+}
+
+// ReadinessProbe reports healthy once the clusters have been discovered and at
+// least one collection succeeded, which the initial collection run by Boot
+// takes care of.
+func (s *Set) ReadinessProbe() *Probe {
+	return &Probe{
+		description: "Checks whether clusters have been discovered and at least one collection succeeded.",
+		name:        "readiness",
+		check:       s.ready,
+	}
+}
+
+// LivenessProbe reports unhealthy as soon as any collection has been running
+// for longer than the stuck threshold.
+func (s *Set) LivenessProbe() *Probe {
+	return &Probe{
+		description: "Checks whether any collection is stuck.",
+		name:        "liveness",
+		check: func() error {
+			name, d, stuck := s.state.stuck(collectionStuckThreshold)
+			if stuck {
+				return fmt.Errorf("collection of %s is running for %s", name, d.Round(time.Second))
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/exporterkit/collector"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+)
+
+func Test_Set_Probes(t *testing.T) {
+	s := &Set{
+		inventory: inventory.New(),
+		state:     newCollectionState(),
+	}
+
+	readiness := s.ReadinessProbe()
+	liveness := s.LivenessProbe()
+
+	r, _ := readiness.GetHealthz(context.Background())
+	if !r.Failed {
+		t.Fatalf("expected readiness to fail before any collection")
+	}
+
+	s.inventory.Sync(nil)
+	id := s.state.start("failing")
+	s.state.finish(id, errors.New("boom"))
+
+	r, _ = readiness.GetHealthz(context.Background())
+	if !r.Failed {
+		t.Fatalf("expected readiness to fail without successful collection")
+	}
+
+	id = s.state.start("succeeding")
+	s.state.finish(id, nil)
+
+	r, _ = readiness.GetHealthz(context.Background())
+	if r.Failed {
+		t.Fatalf("expected readiness to succeed got %#q", r.Message)
+	}
+
+	r, _ = liveness.GetHealthz(context.Background())
+	if r.Failed {
+		t.Fatalf("expected liveness to succeed got %#q", r.Message)
+	}
+
+	s.state.running[42] = collection{name: "stuck", started: time.Now().Add(-2 * collectionStuckThreshold)}
+
+	r, _ = liveness.GetHealthz(context.Background())
+	if !r.Failed {
+		t.Fatalf("expected liveness to fail with stuck collection")
+	}
+}
+
+type syncingCollector struct {
+	inventory *inventory.Inventory
+}
+
+func (c *syncingCollector) Collect(ch chan<- prometheus.Metric) error {
+	c.inventory.Sync(nil)
+	return nil
+}
+
+func (c *syncingCollector) Describe(ch chan<- *prometheus.Desc) error {
+	return nil
+}
+
+func Test_Set_Boot_MakesReady(t *testing.T) {
+	i := inventory.New()
+	state := newCollectionState()
+
+	collectorSet, err := collector.NewSet(collector.SetConfig{
+		Collectors: []collector.Interface{
+			&instrumentedCollector{
+				Interface: &syncingCollector{inventory: i},
+				name:      "syncing",
+				state:     state,
+			},
+		},
+		Logger: microloggertest.New(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Set{
+		Set: collectorSet,
+
+		inventory: i,
+		logger:    microloggertest.New(),
+		state:     state,
+	}
+	defer s.Stop(context.Background())
+
+	r, _ := s.ReadinessProbe().GetHealthz(context.Background())
+	if !r.Failed {
+		t.Fatalf("expected readiness to fail before boot")
+	}
+
+	err = s.Boot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ = s.ReadinessProbe().GetHealthz(context.Background())
+	if r.Failed {
+		t.Fatalf("expected readiness to succeed after boot got %#q", r.Message)
+	}
+}
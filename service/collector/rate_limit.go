@@ -12,11 +12,16 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	azureclient "github.com/giantswarm/azure-collector/v3/client"
 	"github.com/giantswarm/azure-collector/v3/pkg/project"
 	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
 )
 
 const (
+	rateLimitCollectorName = "rate_limit"
+
 	remainingReadsHeaderName  = "x-ms-ratelimit-remaining-subscription-reads"
 	remainingWritesHeaderName = "x-ms-ratelimit-remaining-subscription-writes"
 	resourceGroupNamePrefix   = "azure-collector-empty-rg-for-metrics"
@@ -58,14 +63,18 @@ var (
 
 type RateLimitConfig struct {
 	CtrlClient client.Client
+	Inventory  *inventory.Inventory
 	Logger     micrologger.Logger
+	Shard      sharding.Interface
 	Location   string
 	GSTenantID string
 }
 
 type RateLimit struct {
 	ctrlClient client.Client
+	inventory  *inventory.Inventory
 	logger     micrologger.Logger
+	shard      sharding.Interface
 	location   string
 	gsTenantID string
 }
@@ -82,9 +91,15 @@ func NewRateLimit(config RateLimitConfig) (*RateLimit, error) {
 	if config.CtrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
 	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
 	if config.Location == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Location must not be empty", config)
 	}
@@ -94,7 +109,9 @@ func NewRateLimit(config RateLimitConfig) (*RateLimit, error) {
 
 	u := &RateLimit{
 		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
 		logger:     config.Logger,
+		shard:      config.Shard,
 		location:   config.Location,
 		gsTenantID: config.GSTenantID,
 	}
@@ -116,67 +133,21 @@ func (u *RateLimit) Collect(ch chan<- prometheus.Metric) error {
 	// ClientID.
 	// That way we prevent duplicated metrics.
 	for clientConfig, clientSet := range clientSets {
-		// We want to check only once per subscription
-		if inArray(doneSubscriptions, clientSet.GroupsClient.SubscriptionID) {
+		subscriptionID := clientSet.GroupsClient.SubscriptionID
+		if !u.shard.Owns(subscriptionID) {
 			continue
 		}
 
-		// Remaining write requests can be retrieved sending a write request.
-		var writes float64
-		{
-			resourceGroup := resources.Group{
-				ManagedBy: to.StringPtr(project.Name()),
-				Location:  to.StringPtr(u.location),
-				Tags: map[string]*string{
-					"collector": to.StringPtr(project.Name()),
-				},
-			}
-			resourceGroup, err := clientSet.GroupsClient.CreateOrUpdate(ctx, u.getResourceGroupName(), resourceGroup)
-			if err != nil {
-				u.logger.Debugf(ctx, "clientid %#q gstenantid %#q tenantid %#q", clientConfig.ClientID, clientConfig.GSTenantID, clientConfig.TenantID)
-				return microerror.Mask(err)
-			}
-
-			writes, err = strconv.ParseFloat(resourceGroup.Response.Header.Get(remainingWritesHeaderName), 64)
-			if err != nil {
-				u.logger.Errorf(ctx, err, "an error occurred parsing to float the value inside the rate limiting header for write requests")
-				writes = 0
-				writesErrorCounter.Inc()
-			}
-
-			ch <- prometheus.MustNewConstMetric(
-				writesDesc,
-				prometheus.GaugeValue,
-				writes,
-				clientSet.GroupsClient.SubscriptionID,
-				clientConfig.ClientID,
-			)
-
-			doneSubscriptions = append(doneSubscriptions, clientSet.GroupsClient.SubscriptionID)
+		// We want to check only once per subscription
+		if inArray(doneSubscriptions, subscriptionID) {
+			continue
 		}
+		doneSubscriptions = append(doneSubscriptions, subscriptionID)
 
-		// Remaining read requests can be retrieved sending a read request.
-		var reads float64
-		{
-			groupResponse, err := clientSet.GroupsClient.Get(ctx, u.getResourceGroupName())
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			reads, err = strconv.ParseFloat(groupResponse.Response.Header.Get(remainingReadsHeaderName), 64)
-			if err != nil {
-				u.logger.Errorf(ctx, err, "an error occurred parsing to float the value inside the rate limiting header for read requests")
-				reads = 0
-				readsErrorCounter.Inc()
-			}
-
-			ch <- prometheus.MustNewConstMetric(
-				readsDesc,
-				prometheus.GaugeValue,
-				reads,
-				clientSet.GroupsClient.SubscriptionID,
-				clientConfig.ClientID,
-			)
+		err := u.collectForClientSet(ctx, ch, clientConfig, clientSet)
+		u.inventory.RecordSubscription(subscriptionID, rateLimitCollectorName, err)
+		if err != nil {
+			return microerror.Mask(err)
 		}
 	}
 
@@ -189,6 +160,66 @@ func (u *RateLimit) Describe(ch chan<- *prometheus.Desc) error {
 	return nil
 }
 
+func (u *RateLimit) collectForClientSet(ctx context.Context, ch chan<- prometheus.Metric, clientConfig *azureclient.AzureClientSetConfig, clientSet *azureclient.AzureClientSet) error {
+	// Remaining write requests can be retrieved sending a write request.
+	var writes float64
+	{
+		resourceGroup := resources.Group{
+			ManagedBy: to.StringPtr(project.Name()),
+			Location:  to.StringPtr(u.location),
+			Tags: map[string]*string{
+				"collector": to.StringPtr(project.Name()),
+			},
+		}
+		resourceGroup, err := clientSet.GroupsClient.CreateOrUpdate(ctx, u.getResourceGroupName(), resourceGroup)
+		if err != nil {
+			u.logger.Debugf(ctx, "clientid %#q gstenantid %#q tenantid %#q", clientConfig.ClientID, clientConfig.GSTenantID, clientConfig.TenantID)
+			return microerror.Mask(err)
+		}
+
+		writes, err = strconv.ParseFloat(resourceGroup.Response.Header.Get(remainingWritesHeaderName), 64)
+		if err != nil {
+			u.logger.Errorf(ctx, err, "an error occurred parsing to float the value inside the rate limiting header for write requests")
+			writes = 0
+			writesErrorCounter.Inc()
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			writesDesc,
+			prometheus.GaugeValue,
+			writes,
+			clientSet.GroupsClient.SubscriptionID,
+			clientConfig.ClientID,
+		)
+	}
+
+	// Remaining read requests can be retrieved sending a read request.
+	var reads float64
+	{
+		groupResponse, err := clientSet.GroupsClient.Get(ctx, u.getResourceGroupName())
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		reads, err = strconv.ParseFloat(groupResponse.Response.Header.Get(remainingReadsHeaderName), 64)
+		if err != nil {
+			u.logger.Errorf(ctx, err, "an error occurred parsing to float the value inside the rate limiting header for read requests")
+			reads = 0
+			readsErrorCounter.Inc()
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			readsDesc,
+			prometheus.GaugeValue,
+			reads,
+			clientSet.GroupsClient.SubscriptionID,
+			clientConfig.ClientID,
+		)
+	}
+
+	return nil
+}
+
 func (u *RateLimit) getResourceGroupName() string {
 	return fmt.Sprintf("%s-%s", resourceGroupNamePrefix, u.location)
 }
@@ -0,0 +1,223 @@
+package collector
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/exporterkit/collector"
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	configGenerationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "config", "generation"),
+		"Number of times the settings of the collectors have been applied since the start.",
+		nil,
+		nil,
+	)
+	configReloadFailedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "config", "reload_failed"),
+		"Whether re-creating any collector with the latest settings failed.",
+		nil,
+		nil,
+	)
+	configReloadedTimestampDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "config", "reloaded_timestamp_seconds"),
+		"Unix timestamp of the latest time the settings of the collectors have been applied.",
+		nil,
+		nil,
+	)
+)
+
+// Settings are the parts of the configuration which can change at runtime.
+// Collectors depending on a setting are re-created when it changes.
+type Settings struct {
+	ControlPlaneResourceGroup string
+	GSTenantID                string
+	Location                  string
+
+	// DisabledCollectors are the names of the collectors which are not run.
+	DisabledCollectors   []string
+	LoadBalancerNames    []string
+	ResourceGroupTagKeys []string
+	UsageLocations       []string
+}
+
+// reloadableCollector re-creates the wrapped collector whenever the settings
+// it uses change.
+type reloadableCollector struct {
+	name string
+	// uses returns the settings the collector depends on, so that it is only
+	// re-created when one of those changes.
+	uses   func(s Settings) Settings
+	create func(s Settings) (collector.Interface, error)
+
+	mutex    sync.RWMutex
+	current  collector.Interface
+	settings Settings
+}
+
+// reload re-creates the collector if the settings it uses differ from the ones
+// it has been created with. It returns whether the collector was re-created.
+// The previous collector keeps running if creating the new one fails.
+func (r *reloadableCollector) reload(settings Settings) (bool, error) {
+	r.mutex.RLock()
+	unchanged := r.current != nil && reflect.DeepEqual(r.uses(settings), r.uses(r.settings))
+	r.mutex.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	c, err := r.create(settings)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	r.mutex.Lock()
+	r.current = c
+	r.settings = settings
+	r.mutex.Unlock()
+
+	return true, nil
+}
+
+func (r *reloadableCollector) Collect(ch chan<- prometheus.Metric) error {
+	r.mutex.RLock()
+	c := r.current
+	r.mutex.RUnlock()
+
+	err := c.Collect(ch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *reloadableCollector) Name() string {
+	return r.name
+}
+
+func (r *reloadableCollector) Describe(ch chan<- *prometheus.Desc) error {
+	r.mutex.RLock()
+	c := r.current
+	r.mutex.RUnlock()
+
+	err := c.Describe(ch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// configState tracks the settings applied to the collectors and exposes them
+// as metrics.
+type configState struct {
+	mutex      sync.Mutex
+	settings   Settings
+	generation int
+	failed     bool
+	reloadedAt time.Time
+}
+
+func (s *configState) Collect(ch chan<- prometheus.Metric) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(
+		configGenerationDesc,
+		prometheus.GaugeValue,
+		float64(s.generation),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		configReloadFailedDesc,
+		prometheus.GaugeValue,
+		boolToFloat64(s.failed),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		configReloadedTimestampDesc,
+		prometheus.GaugeValue,
+		float64(s.reloadedAt.Unix()),
+	)
+
+	return nil
+}
+
+// disabled returns whether the collector with the given name is disabled in
+// the applied settings.
+func (s *configState) disabled(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, d := range s.settings.DisabledCollectors {
+		if d == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *configState) Name() string {
+	return "config"
+}
+
+func (s *configState) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- configGenerationDesc
+	ch <- configReloadFailedDesc
+	ch <- configReloadedTimestampDesc
+	return nil
+}
+
+// Reload applies the given settings, re-creating only the collectors which
+// depend on settings that changed. Collectors which cannot be re-created keep
+// running with their previous settings and are retried on the next reload.
+func (s *Set) Reload(settings Settings) error {
+	s.config.mutex.Lock()
+	defer s.config.mutex.Unlock()
+
+	var reloadErr error
+	var reloaded bool
+	for _, r := range s.reloadables {
+		ok, err := r.reload(settings)
+		if err != nil {
+			reloadErr = microerror.Maskf(reloadFailedError, "collector %#q: %s", r.name, err)
+			continue
+		}
+
+		reloaded = reloaded || ok
+	}
+
+	s.config.failed = reloadErr != nil
+	if reloaded || !reflect.DeepEqual(settings, s.config.settings) {
+		s.config.settings = settings
+		s.config.generation++
+		s.config.reloadedAt = time.Now()
+	}
+
+	return reloadErr
+}
+
+// toggledCollector skips the wrapped collector while it is disabled in the
+// settings applied to the set.
+type toggledCollector struct {
+	namedCollector
+
+	config *configState
+}
+
+func (c *toggledCollector) Collect(ch chan<- prometheus.Metric) error {
+	if c.config.disabled(c.Name()) {
+		return nil
+	}
+
+	err := c.namedCollector.Collect(ch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/giantswarm/exporterkit/collector"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type fakeCollector struct {
+	settings Settings
+}
+
+func (c *fakeCollector) Collect(ch chan<- prometheus.Metric) error {
+	return nil
+}
+
+func (c *fakeCollector) Describe(ch chan<- *prometheus.Desc) error {
+	return nil
+}
+
+func Test_Set_Reload(t *testing.T) {
+	var created []string
+	newReloadable := func(name string, uses func(s Settings) Settings) *reloadableCollector {
+		return &reloadableCollector{
+			name: name,
+			uses: uses,
+			create: func(s Settings) (collector.Interface, error) {
+				if s.GSTenantID == "" {
+					return nil, errors.New("empty tenant")
+				}
+				created = append(created, name)
+				return &fakeCollector{settings: s}, nil
+			},
+		}
+	}
+
+	location := newReloadable("location", func(s Settings) Settings {
+		return Settings{Location: s.Location}
+	})
+	tenant := newReloadable("tenant", func(s Settings) Settings {
+		return Settings{GSTenantID: s.GSTenantID}
+	})
+
+	s := &Set{
+		config:      &configState{},
+		reloadables: []*reloadableCollector{location, tenant},
+	}
+
+	initial := Settings{GSTenantID: "tenant-a", Location: "westeurope"}
+	err := s.Reload(initial)
+	if err != nil {
+		t.Fatalf("expected no error got %#v", err)
+	}
+	if len(created) != 2 || s.config.generation != 1 {
+		t.Fatalf("expected both collectors to be created in generation 1, got %v in generation %d", created, s.config.generation)
+	}
+
+	created = nil
+	err = s.Reload(initial)
+	if err != nil {
+		t.Fatalf("expected no error got %#v", err)
+	}
+	if len(created) != 0 || s.config.generation != 1 {
+		t.Fatalf("expected nothing to be re-created, got %v in generation %d", created, s.config.generation)
+	}
+
+	err = s.Reload(Settings{GSTenantID: "tenant-a", Location: "germanywestcentral"})
+	if err != nil {
+		t.Fatalf("expected no error got %#v", err)
+	}
+	if len(created) != 1 || created[0] != "location" || s.config.generation != 2 {
+		t.Fatalf("expected only the location collector to be re-created in generation 2, got %v in generation %d", created, s.config.generation)
+	}
+	if location.current.(*fakeCollector).settings.Location != "germanywestcentral" {
+		t.Fatalf("expected location collector to use the new location")
+	}
+
+	created = nil
+	err = s.Reload(Settings{Location: "germanywestcentral"})
+	if !IsReloadFailed(err) {
+		t.Fatalf("expected reload failed error got %#v", err)
+	}
+	if !s.config.failed {
+		t.Fatalf("expected failed reload to be tracked")
+	}
+	if tenant.current.(*fakeCollector).settings.GSTenantID != "tenant-a" {
+		t.Fatalf("expected tenant collector to keep its previous settings")
+	}
+}
+
+type countingCollector struct {
+	collections int
+}
+
+func (c *countingCollector) Collect(ch chan<- prometheus.Metric) error {
+	c.collections++
+	return nil
+}
+
+func (c *countingCollector) Describe(ch chan<- *prometheus.Desc) error {
+	return nil
+}
+
+func Test_Set_Reload_DisabledCollectors(t *testing.T) {
+	var created int
+	usage := &reloadableCollector{
+		name: "usage",
+		uses: func(s Settings) Settings {
+			return Settings{UsageLocations: s.UsageLocations}
+		},
+		create: func(s Settings) (collector.Interface, error) {
+			created++
+			return &countingCollector{}, nil
+		},
+	}
+
+	s := &Set{
+		config:      &configState{},
+		reloadables: []*reloadableCollector{usage},
+	}
+	toggled := &toggledCollector{
+		namedCollector: usage,
+		config:         s.config,
+	}
+
+	err := s.Reload(Settings{UsageLocations: []string{"westeurope"}})
+	if err != nil {
+		t.Fatalf("expected no error got %#v", err)
+	}
+
+	err = s.Reload(Settings{UsageLocations: []string{"westeurope"}, DisabledCollectors: []string{"usage"}})
+	if err != nil {
+		t.Fatalf("expected no error got %#v", err)
+	}
+	if created != 1 {
+		t.Fatalf("expected disabling not to re-create the collector, created %d times", created)
+	}
+	if s.config.generation != 2 {
+		t.Fatalf("expected disabling to be a new generation got %d", s.config.generation)
+	}
+
+	err = toggled.Collect(nil)
+	if err != nil {
+		t.Fatalf("expected no error got %#v", err)
+	}
+	if usage.current.(*countingCollector).collections != 0 {
+		t.Fatalf("expected disabled collector not to collect")
+	}
+
+	err = s.Reload(Settings{UsageLocations: []string{"westeurope", "germanywestcentral"}})
+	if err != nil {
+		t.Fatalf("expected no error got %#v", err)
+	}
+	if created != 2 {
+		t.Fatalf("expected changed usage locations to re-create the collector, created %d times", created)
+	}
+
+	err = toggled.Collect(nil)
+	if err != nil {
+		t.Fatalf("expected no error got %#v", err)
+	}
+	if usage.current.(*countingCollector).collections != 1 {
+		t.Fatalf("expected enabled collector to collect")
+	}
+}
@@ -2,24 +2,56 @@ package collector
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2016-09-01/locks"     //nolint:staticcheck
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources" //nolint:staticcheck
 	"github.com/Azure/go-autorest/autorest/to"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/errgroup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capzv1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	azureclient "github.com/giantswarm/azure-collector/v3/client"
 	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
 )
 
 const (
+	resourceGroupCollectorName = "resource_group"
+
 	labelID        = "id"
 	labelName      = "name"
 	labelState     = "state"
 	labelLocation  = "location"
 	labelManagedBy = "managed_by"
+	labelLockName  = "lock_name"
+	labelLockLevel = "level"
+
+	// tagLabelPrefix is prepended to the sanitized tag keys exposed as
+	// labels.
+	tagLabelPrefix = "tag_"
+	// lockScopeSeparator separates the scope of a lock from its name in the
+	// lock ID.
+	lockScopeSeparator = "/providers/microsoft.authorization/locks/"
+
+	// clusterTag and vintageClusterTag hold the ID of the cluster owning a
+	// resource group. CAPZ instead tags resource groups with
+	// capzv1beta1.NameAzureProviderOwned followed by the cluster name.
+	clusterTag        = "giantswarm.io/cluster"
+	vintageClusterTag = "GiantSwarmCluster"
+
+	provisioningStateDeleting = "Deleting"
 )
 
 var (
@@ -36,38 +68,128 @@ var (
 		nil,
 	)
 
+	resourceGroupOrphanedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "resource_group", "orphaned"),
+		"Resource group tagged as owned by a cluster which does not exist. The value is always 1.",
+		[]string{
+			labelSubscriptionId,
+			labelName,
+			labelLocation,
+			labelClusterID,
+		},
+		nil,
+	)
+	resourceGroupOrphanedAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "resource_group", "orphaned_age_seconds"),
+		"Age in seconds of the oldest resource in a resource group tagged as owned by a cluster which does not exist.",
+		[]string{
+			labelSubscriptionId,
+			labelName,
+			labelClusterID,
+		},
+		nil,
+	)
+
+	resourceGroupLockDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "resource_group", "lock"),
+		"Management lock of a resource group. The value is always 1.",
+		[]string{
+			labelSubscriptionId,
+			labelName,
+			labelLockName,
+			labelLockLevel,
+		},
+		nil,
+	)
+	resourceGroupDeleteLockedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "resource_group", "delete_locked"),
+		"Whether a management lock of a resource group prevents its deletion.",
+		[]string{
+			labelSubscriptionId,
+			labelName,
+		},
+		nil,
+	)
+
 	gaugeValue float64 = 1
+
+	invalidLabelCharacters = regexp.MustCompile(`[^a-zA-Z0-9_]`)
 )
 
 type ResourceGroupConfig struct {
 	CtrlClient client.Client
+	Inventory  *inventory.Inventory
 	Logger     micrologger.Logger
+	Shard      sharding.Interface
 	GSTenantID string
+	// TagKeys is optional. The values of these tags of every resource group
+	// are exposed as labels of azure_operator_resource_group_tags.
+	TagKeys []string
 }
 
 type ResourceGroup struct {
 	ctrlClient client.Client
+	inventory  *inventory.Inventory
 	logger     micrologger.Logger
+	shard      sharding.Interface
 	gsTenantID string
+	tagKeys    []string
+
+	tagsDesc *prometheus.Desc
 }
 
 // NewResourceGroup exposes metrics on the existing resource groups for every subscription.
 // It exposes metrcis about the subscriptions found in the "credential-*" secrets of the control plane.
+// Resource groups tagged as owned by a cluster which is neither a vintage nor a CAPI cluster of the control plane are exposed as orphaned.
+// It also exposes the configured tags and the management locks of every resource group, so that tagging for cost allocation and missing delete locks can be checked.
 func NewResourceGroup(config ResourceGroupConfig) (*ResourceGroup, error) {
 	if config.CtrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
 	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
 	if config.GSTenantID == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
 	}
 
+	tagLabels := []string{
+		labelSubscriptionId,
+		labelName,
+	}
+	{
+		seen := map[string]string{}
+		for _, k := range config.TagKeys {
+			l := tagLabel(k)
+			if other, ok := seen[l]; ok {
+				return nil, microerror.Maskf(invalidConfigError, "%T.TagKeys %q and %q must not map to the same label %q", config, other, k, l)
+			}
+			seen[l] = k
+
+			tagLabels = append(tagLabels, l)
+		}
+	}
+
 	r := &ResourceGroup{
 		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
 		logger:     config.Logger,
+		shard:      config.Shard,
 		gsTenantID: config.GSTenantID,
+		tagKeys:    config.TagKeys,
+
+		tagsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(MetricsNamespace, "resource_group", "tags"),
+			"Configured tags of a resource group exposed as labels. The value is always 1.",
+			tagLabels,
+			nil,
+		),
 	}
 
 	return r, nil
@@ -80,13 +202,24 @@ func (r *ResourceGroup) Collect(ch chan<- prometheus.Metric) error {
 		return microerror.Mask(err)
 	}
 
+	known, err := r.getKnownClusters(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
 	var g errgroup.Group
 
-	for _, item := range clientSets {
+	for subscriptionID, item := range clientSets {
+		if !r.shard.Owns(subscriptionID) {
+			continue
+		}
+
+		subscriptionID := subscriptionID
 		clientSet := item
 
 		g.Go(func() error {
-			err := r.collectForClientSet(ctx, ch, clientSet.GroupsClient)
+			err := r.collectForClientSet(ctx, ch, subscriptionID, clientSet, known)
+			r.inventory.RecordSubscription(subscriptionID, resourceGroupCollectorName, err)
 			if err != nil {
 				return microerror.Mask(err)
 			}
@@ -102,12 +235,18 @@ func (r *ResourceGroup) Collect(ch chan<- prometheus.Metric) error {
 	return nil
 }
 
-func (r *ResourceGroup) collectForClientSet(ctx context.Context, ch chan<- prometheus.Metric, client *resources.GroupsClient) error {
-	resultsPage, err := client.ListComplete(context.Background(), "", nil)
+func (r *ResourceGroup) collectForClientSet(ctx context.Context, ch chan<- prometheus.Metric, subscriptionID string, clientSet *azureclient.AzureClientSet, known knownClusters) error {
+	resultsPage, err := clientSet.GroupsClient.ListComplete(context.Background(), "", nil)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
+	// Failing to list the locks only omits the lock metrics.
+	locksByScope, err := listLocksByScope(ctx, clientSet.ManagementLocksClient)
+	if err != nil {
+		r.logger.Errorf(ctx, err, "Unable to list management locks in subscription %q", subscriptionID)
+	}
+
 	for resultsPage.NotDone() {
 		group := resultsPage.Value()
 		ch <- prometheus.MustNewConstMetric(
@@ -121,6 +260,23 @@ func (r *ResourceGroup) collectForClientSet(ctx context.Context, ch chan<- prome
 			to.String(group.ManagedBy),
 		)
 
+		if len(r.tagKeys) > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				r.tagsDesc,
+				prometheus.GaugeValue,
+				gaugeValue,
+				append([]string{subscriptionID, to.String(group.Name)}, tagValues(group.Tags, r.tagKeys)...)...,
+			)
+		}
+
+		if locksByScope != nil {
+			r.collectLocks(ch, subscriptionID, group, locksByScope[strings.ToLower(to.String(group.ID))])
+		}
+
+		if clusterID, ok := known.orphanedBy(group); ok {
+			r.collectOrphaned(ctx, ch, subscriptionID, clusterID, group, clientSet.ResourcesClient)
+		}
+
 		if err := resultsPage.NextWithContext(ctx); err != nil {
 			return microerror.Mask(err)
 		}
@@ -131,6 +287,11 @@ func (r *ResourceGroup) collectForClientSet(ctx context.Context, ch chan<- prome
 
 func (r *ResourceGroup) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- resourceGroupDesc
+	ch <- resourceGroupOrphanedDesc
+	ch <- resourceGroupOrphanedAgeDesc
+	ch <- resourceGroupLockDesc
+	ch <- resourceGroupDeleteLockedDesc
+	ch <- r.tagsDesc
 
 	return nil
 }
@@ -142,3 +303,278 @@ func getState(group resources.Group) string {
 
 	return ""
 }
+
+func (r *ResourceGroup) collectLocks(ch chan<- prometheus.Metric, subscriptionID string, group resources.Group, groupLocks []locks.ManagementLockObject) {
+	var deleteLocked bool
+	for _, l := range groupLocks {
+		var level locks.LockLevel
+		if l.ManagementLockProperties != nil {
+			level = l.Level
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			resourceGroupLockDesc,
+			prometheus.GaugeValue,
+			gaugeValue,
+			subscriptionID,
+			to.String(group.Name),
+			to.String(l.Name),
+			string(level),
+		)
+
+		// Read only locks prevent the deletion as well.
+		if level == locks.CanNotDelete || level == locks.ReadOnly {
+			deleteLocked = true
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		resourceGroupDeleteLockedDesc,
+		prometheus.GaugeValue,
+		boolToFloat64(deleteLocked),
+		subscriptionID,
+		to.String(group.Name),
+	)
+}
+
+func (r *ResourceGroup) collectOrphaned(ctx context.Context, ch chan<- prometheus.Metric, subscriptionID, clusterID string, group resources.Group, resourcesClient *resources.Client) {
+	ch <- prometheus.MustNewConstMetric(
+		resourceGroupOrphanedDesc,
+		prometheus.GaugeValue,
+		gaugeValue,
+		subscriptionID,
+		to.String(group.Name),
+		to.String(group.Location),
+		clusterID,
+	)
+
+	// Resource groups have no creation time, so the age is derived from the
+	// oldest resource in the group.
+	oldest, err := oldestResource(ctx, resourcesClient, to.String(group.Name))
+	if err != nil {
+		r.logger.Errorf(ctx, err, "Unable to list resources of resource group %q in subscription %q", to.String(group.Name), subscriptionID)
+		return
+	}
+	if oldest.IsZero() {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		resourceGroupOrphanedAgeDesc,
+		prometheus.GaugeValue,
+		time.Since(oldest).Seconds(),
+		subscriptionID,
+		to.String(group.Name),
+		clusterID,
+	)
+}
+
+// getKnownClusters returns the IDs and resource groups of the vintage and CAPI
+// clusters of the control plane.
+func (r *ResourceGroup) getKnownClusters(ctx context.Context) (knownClusters, error) {
+	known := knownClusters{
+		clusterIDs:     map[string]bool{},
+		resourceGroups: map[string]bool{},
+	}
+
+	{
+		mark := ""
+		page := 0
+		for page == 0 || len(mark) > 0 {
+			opts := client.ListOptions{
+				Continue: mark,
+			}
+			list := providerv1alpha1.AzureConfigList{}
+			err := r.ctrlClient.List(ctx, &list, &opts)
+			if err != nil {
+				return knownClusters{}, microerror.Mask(err)
+			}
+
+			for _, cr := range list.Items {
+				known.add(cr.Name, cr.Name)
+			}
+
+			mark = list.Continue
+			page++
+		}
+	}
+
+	{
+		list := &capiv1beta1.ClusterList{}
+		err := r.ctrlClient.List(ctx, list, client.InNamespace(metav1.NamespaceAll))
+		if err != nil {
+			return knownClusters{}, microerror.Mask(err)
+		}
+
+		for _, cr := range list.Items {
+			known.add(cr.Name, cr.Name)
+		}
+	}
+
+	{
+		list := &capzv1beta1.AzureClusterList{}
+		err := r.ctrlClient.List(ctx, list, client.InNamespace(metav1.NamespaceAll))
+		if err != nil {
+			return knownClusters{}, microerror.Mask(err)
+		}
+
+		for _, cr := range list.Items {
+			known.add(cr.Name, cr.Spec.ResourceGroup)
+		}
+	}
+
+	return known, nil
+}
+
+// knownClusters holds the lower case IDs and resource groups of the clusters
+// of the control plane.
+type knownClusters struct {
+	clusterIDs     map[string]bool
+	resourceGroups map[string]bool
+}
+
+func (k knownClusters) add(clusterID, resourceGroup string) {
+	if clusterID != "" {
+		k.clusterIDs[strings.ToLower(clusterID)] = true
+	}
+	if resourceGroup != "" {
+		k.resourceGroups[strings.ToLower(resourceGroup)] = true
+	}
+}
+
+// orphanedBy returns the ID of the cluster the given resource group is tagged
+// with, if that cluster is not known and the resource group is not being
+// deleted already.
+func (k knownClusters) orphanedBy(group resources.Group) (string, bool) {
+	if getState(group) == provisioningStateDeleting {
+		return "", false
+	}
+
+	clusterID, ok := owningCluster(group.Tags)
+	if !ok {
+		return "", false
+	}
+
+	if k.clusterIDs[strings.ToLower(clusterID)] || k.resourceGroups[strings.ToLower(to.String(group.Name))] {
+		return "", false
+	}
+
+	return clusterID, true
+}
+
+// owningCluster returns the ID of the cluster the given resource group tags
+// mark as owner. CAPZ tags resource groups it does not manage, e.g. ones
+// brought by the customer, as shared, so only its owned tags are considered.
+func owningCluster(tags map[string]*string) (string, bool) {
+	for _, t := range []string{clusterTag, vintageClusterTag} {
+		v, ok := tags[t]
+		if ok && v != nil && *v != "" {
+			return *v, true
+		}
+	}
+
+	var keys []string
+	for t := range tags {
+		keys = append(keys, t)
+	}
+	sort.Strings(keys)
+
+	for _, t := range keys {
+		if !strings.HasPrefix(t, capzv1beta1.NameAzureProviderOwned) {
+			continue
+		}
+		if capzv1beta1.ResourceLifecycle(to.String(tags[t])) != capzv1beta1.ResourceLifecycleOwned {
+			continue
+		}
+
+		return strings.TrimPrefix(t, capzv1beta1.NameAzureProviderOwned), true
+	}
+
+	return "", false
+}
+
+// oldestResource returns the creation time of the oldest resource in the given
+// resource group, which is zero when the group is empty.
+func oldestResource(ctx context.Context, resourcesClient *resources.Client, resourceGroup string) (time.Time, error) {
+	var oldest time.Time
+
+	r, err := resourcesClient.ListByResourceGroupComplete(ctx, resourceGroup, "", "createdTime", nil)
+	if err != nil {
+		return time.Time{}, microerror.Mask(err)
+	}
+
+	for r.NotDone() {
+		v := r.Value()
+		if v.CreatedTime != nil && (oldest.IsZero() || v.CreatedTime.Time.Before(oldest)) {
+			oldest = v.CreatedTime.Time
+		}
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			return time.Time{}, microerror.Mask(err)
+		}
+	}
+
+	return oldest, nil
+}
+
+// listLocksByScope returns the management locks of the subscription keyed by
+// the lower case ID of the resource or resource group they are defined on.
+// Locks inherited from the subscription are not taken into account.
+func listLocksByScope(ctx context.Context, locksClient *locks.ManagementLocksClient) (map[string][]locks.ManagementLockObject, error) {
+	var all []locks.ManagementLockObject
+
+	r, err := locksClient.ListAtSubscriptionLevelComplete(ctx, "")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for r.NotDone() {
+		all = append(all, r.Value())
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return locksByScope(all), nil
+}
+
+func locksByScope(all []locks.ManagementLockObject) map[string][]locks.ManagementLockObject {
+	byScope := map[string][]locks.ManagementLockObject{}
+	for _, l := range all {
+		id := strings.ToLower(to.String(l.ID))
+
+		i := strings.LastIndex(id, lockScopeSeparator)
+		if i < 0 {
+			continue
+		}
+
+		byScope[id[:i]] = append(byScope[id[:i]], l)
+	}
+
+	return byScope
+}
+
+// tagLabel returns the label exposing the value of the given tag key, e.g.
+// "tag_giantswarm_io_cluster" for "giantswarm.io/cluster".
+func tagLabel(key string) string {
+	return fmt.Sprintf("%s%s", tagLabelPrefix, invalidLabelCharacters.ReplaceAllString(strings.ToLower(key), "_"))
+}
+
+// tagValues returns the values of the given tag keys, which are empty for
+// missing tags. Like in Azure, tag keys are case insensitive.
+func tagValues(tags map[string]*string, keys []string) []string {
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		for t, v := range tags {
+			if strings.EqualFold(t, k) {
+				values[i] = to.String(v)
+				break
+			}
+		}
+	}
+
+	return values
+}
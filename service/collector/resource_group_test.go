@@ -0,0 +1,189 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2016-09-01/locks"     //nolint:staticcheck
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources" //nolint:staticcheck
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_knownClusters_orphanedBy(t *testing.T) {
+	known := knownClusters{
+		clusterIDs:     map[string]bool{},
+		resourceGroups: map[string]bool{},
+	}
+	known.add("abc12", "abc12")
+	known.add("Def34", "custom-rg")
+
+	testCases := []struct {
+		name              string
+		group             resources.Group
+		expectedClusterID string
+		expectedOrphaned  bool
+	}{
+		{
+			name: "case 0: resource group without cluster tags is not orphaned",
+			group: resources.Group{
+				Name: to.StringPtr("some-rg"),
+				Tags: map[string]*string{"team": to.StringPtr("phoenix")},
+			},
+		},
+		{
+			name: "case 1: resource group of an existing vintage cluster is not orphaned",
+			group: resources.Group{
+				Name: to.StringPtr("abc12"),
+				Tags: map[string]*string{vintageClusterTag: to.StringPtr("abc12")},
+			},
+		},
+		{
+			name: "case 2: cluster IDs are compared case insensitively",
+			group: resources.Group{
+				Name: to.StringPtr("def34"),
+				Tags: map[string]*string{clusterTag: to.StringPtr("def34")},
+			},
+		},
+		{
+			name: "case 3: resource group tagged with an unknown cluster is orphaned",
+			group: resources.Group{
+				Name: to.StringPtr("xyz99"),
+				Tags: map[string]*string{clusterTag: to.StringPtr("xyz99")},
+			},
+			expectedClusterID: "xyz99",
+			expectedOrphaned:  true,
+		},
+		{
+			name: "case 4: resource group owned by an unknown CAPZ cluster is orphaned",
+			group: resources.Group{
+				Name: to.StringPtr("capz-rg"),
+				Tags: map[string]*string{"sigs.k8s.io_cluster-api-provider-azure_cluster_gone1": to.StringPtr("owned")},
+			},
+			expectedClusterID: "gone1",
+			expectedOrphaned:  true,
+		},
+		{
+			name: "case 5: resource group of an AzureCluster is not orphaned",
+			group: resources.Group{
+				Name: to.StringPtr("custom-rg"),
+				Tags: map[string]*string{clusterTag: to.StringPtr("other")},
+			},
+		},
+		{
+			name: "case 6: resource group being deleted is not orphaned",
+			group: resources.Group{
+				Name:       to.StringPtr("xyz99"),
+				Tags:       map[string]*string{clusterTag: to.StringPtr("xyz99")},
+				Properties: &resources.GroupProperties{ProvisioningState: to.StringPtr(provisioningStateDeleting)},
+			},
+		},
+		{
+			name: "case 7: resource group shared with an unknown CAPZ cluster is not orphaned",
+			group: resources.Group{
+				Name: to.StringPtr("byo-rg"),
+				Tags: map[string]*string{"sigs.k8s.io_cluster-api-provider-azure_cluster_gone1": to.StringPtr("shared")},
+			},
+		},
+		{
+			name: "case 8: the owned CAPZ tag is used when the group is also shared",
+			group: resources.Group{
+				Name: to.StringPtr("capz-rg"),
+				Tags: map[string]*string{
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_abc12": to.StringPtr("shared"),
+					"sigs.k8s.io_cluster-api-provider-azure_cluster_gone1": to.StringPtr("owned"),
+				},
+			},
+			expectedClusterID: "gone1",
+			expectedOrphaned:  true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			clusterID, orphaned := known.orphanedBy(tc.group)
+			if orphaned != tc.expectedOrphaned {
+				t.Fatalf("orphaned = %v, want %v", orphaned, tc.expectedOrphaned)
+			}
+			if clusterID != tc.expectedClusterID {
+				t.Fatalf("cluster ID = %q, want %q", clusterID, tc.expectedClusterID)
+			}
+		})
+	}
+}
+
+func Test_locksByScope(t *testing.T) {
+	all := []locks.ManagementLockObject{
+		{
+			ID:   to.StringPtr("/subscriptions/s/resourceGroups/Abc12/providers/Microsoft.Authorization/locks/delete"),
+			Name: to.StringPtr("delete"),
+		},
+		{
+			ID:   to.StringPtr("/subscriptions/s/resourceGroups/abc12/providers/Microsoft.Network/publicIPAddresses/ip/providers/Microsoft.Authorization/locks/ip"),
+			Name: to.StringPtr("ip"),
+		},
+		{
+			ID:   to.StringPtr("/subscriptions/s/providers/Microsoft.Authorization/locks/subscription"),
+			Name: to.StringPtr("subscription"),
+		},
+	}
+
+	byScope := locksByScope(all)
+
+	groupLocks := byScope["/subscriptions/s/resourcegroups/abc12"]
+	if len(groupLocks) != 1 || to.String(groupLocks[0].Name) != "delete" {
+		t.Fatalf("expected only lock %q for resource group, got %v", "delete", groupLocks)
+	}
+	if len(byScope) != 3 {
+		t.Fatalf("expected 3 scopes, got %d", len(byScope))
+	}
+}
+
+func Test_tagValues(t *testing.T) {
+	testCases := []struct {
+		name           string
+		tags           map[string]*string
+		keys           []string
+		expectedLabels []string
+		expectedValues []string
+	}{
+		{
+			name:           "case 0: missing tags are empty",
+			tags:           nil,
+			keys:           []string{clusterTag},
+			expectedLabels: []string{"tag_giantswarm_io_cluster"},
+			expectedValues: []string{""},
+		},
+		{
+			name: "case 1: tag keys are case insensitive",
+			tags: map[string]*string{
+				"GiantSwarm.io/Organization": to.StringPtr("acme"),
+				"CostCenter":                 to.StringPtr("1234"),
+			},
+			keys:           []string{"giantswarm.io/organization", "costcenter"},
+			expectedLabels: []string{"tag_giantswarm_io_organization", "tag_costcenter"},
+			expectedValues: []string{"acme", "1234"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var labels []string
+			for _, k := range tc.keys {
+				labels = append(labels, tagLabel(k))
+			}
+			if !cmp.Equal(labels, tc.expectedLabels) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedLabels, labels))
+			}
+
+			values := tagValues(tc.tags, tc.keys)
+			if !cmp.Equal(values, tc.expectedValues) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedValues, values))
+			}
+		})
+	}
+}
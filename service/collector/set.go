@@ -1,26 +1,64 @@
 package collector
 
 import (
+	"time"
+
 	"github.com/giantswarm/exporterkit/collector"
 	"github.com/giantswarm/k8sclient/v7/pkg/k8sclient"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 
 	"github.com/giantswarm/azure-collector/v3/service/collector/cluster"
+	"github.com/giantswarm/azure-collector/v3/service/collector/sku"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
 )
 
 const (
 	MetricsNamespace = "azure_operator"
 
 	gsTenantID = "31f75bf9-3d8c-4691-95c0-83dd71613db8"
+
+	// clusterCollectorName and clusterAzureCollectorName name the cluster
+	// collectors reading CRs only and the ones calling the Azure APIs.
+	clusterCollectorName      = "cluster"
+	clusterAzureCollectorName = "cluster_azure"
+
+	// vmSKUCacheTTL is how long the VM sizes of a location are reused by the
+	// collectors needing them. They only change when Azure adds sizes or
+	// restricts them for a subscription.
+	vmSKUCacheTTL = time.Hour
 )
 
 type SetConfig struct {
-	K8sClient                 k8sclient.Interface
-	Location                  string
-	Logger                    micrologger.Logger
+	Inventory *inventory.Inventory
+	K8sClient k8sclient.Interface
+	// Leader is optional. When set, only the leader polls the Azure APIs.
+	Leader Leader
+	Logger micrologger.Logger
+	Shard  sharding.Interface
+	// HistogramsConfigMap is optional. When set, the histograms of cluster
+	// creation and upgrade durations are persisted in this ConfigMap.
+	HistogramsConfigMap string
+	HistogramsNamespace string
+
+	// The settings below are applied initially and can be changed at runtime
+	// using Set.Reload.
 	ControlPlaneResourceGroup string
 	GSTenantID                string
+	Location                  string
+	// DisabledCollectors is optional. Collectors with these names are not
+	// run.
+	DisabledCollectors []string
+	// LoadBalancerNames is optional. When set, only the load balancers with
+	// these names are collected instead of all in the cluster resource group.
+	LoadBalancerNames []string
+	// ResourceGroupTagKeys is optional. When set, the values of these tags
+	// of every resource group are exposed as labels.
+	ResourceGroupTagKeys []string
+	// UsageLocations is optional. When set, usages are collected for these
+	// locations instead of the locations of the clusters.
+	UsageLocations []string
 }
 
 // Set is basically only a wrapper for the operator's collector implementations.
@@ -28,14 +66,35 @@ type SetConfig struct {
 // have to alias packages.
 type Set struct {
 	*collector.Set
+
+	config      *configState
+	inventory   *inventory.Inventory
+	logger      micrologger.Logger
+	reloadables []*reloadableCollector
+	state       *collectionState
 }
 
 func NewSet(config SetConfig) (*Set, error) {
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
+
 	var err error
-	var collectors []collector.Interface
+	var collectors []namedCollector
+	var clusterCollectors *cluster.Collectors
+
+	skuCache := sku.NewCache(vmSKUCacheTTL)
 
 	{
-		clusterCollectors, err := cluster.NewCollectors(config.K8sClient.CtrlClient(), config.Logger)
+		clusterCollectors, err = cluster.NewCollectors(clusterCollectorName, config.K8sClient.CtrlClient(), config.Inventory, config.Leader, config.Logger, config.Shard)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		clusterAzureCollectors, err := cluster.NewCollectors(clusterAzureCollectorName, config.K8sClient.CtrlClient(), config.Inventory, config.Leader, config.Logger, config.Shard)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
@@ -55,147 +114,498 @@ func NewSet(config SetConfig) (*Set, error) {
 			return nil, microerror.Mask(err)
 		}
 
-		transition, err := cluster.NewTransitionTime(config.K8sClient.CtrlClient(), config.Logger)
+		var histograms *cluster.DurationHistograms
+		{
+			c := cluster.DurationHistogramsConfig{
+				K8sClient: config.K8sClient.K8sClient(),
+				Logger:    config.Logger,
+
+				ConfigMapName:      config.HistogramsConfigMap,
+				ConfigMapNamespace: config.HistogramsNamespace,
+			}
+
+			histograms, err = cluster.NewDurationHistograms(c)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		transition, err := cluster.NewTransitionTime(config.K8sClient.CtrlClient(), histograms, config.Logger)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 
+		quotaHeadroom, err := cluster.NewQuotaHeadroom(config.K8sClient.CtrlClient(), config.Logger, skuCache)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		// Only the collectors calling the Azure APIs are restricted to the
+		// leader. The ones reading CRs only run on every replica.
 		clusterCollectors.Add(conditions)
-		clusterCollectors.Add(nodepools)
 		clusterCollectors.Add(releases)
 		clusterCollectors.Add(transition)
-		collectors = append(collectors, clusterCollectors)
+		clusterAzureCollectors.Add(nodepools)
+		clusterAzureCollectors.Add(quotaHeadroom)
+		collectors = append(collectors, clusterAzureCollectors)
 	}
 
+	var reloadables []*reloadableCollector
+
 	{
-		c := DeploymentConfig{
-			CtrlClient: config.K8sClient.CtrlClient(),
-			Logger:     config.Logger,
-			GSTenantID: config.GSTenantID,
+		r := &reloadableCollector{
+			name: credentialHealthCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := CredentialHealthConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+				}
+
+				credentialHealthCollector, err := NewCredentialHealth(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return credentialHealthCollector, nil
+			},
 		}
 
-		deploymentCollector, err := NewDeployment(c)
-		if err != nil {
-			return nil, microerror.Mask(err)
+		reloadables = append(reloadables, r)
+	}
+
+	{
+		r := &reloadableCollector{
+			name: deletionCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := DeletionConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+				}
+
+				deletionCollector, err := NewDeletion(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return deletionCollector, nil
+			},
 		}
 
-		collectors = append(collectors, deploymentCollector)
+		reloadables = append(reloadables, r)
 	}
 
 	{
-		c := LoadBalancerConfig{
-			CtrlClient: config.K8sClient.CtrlClient(),
-			Logger:     config.Logger,
-			GSTenantID: config.GSTenantID,
+		r := &reloadableCollector{
+			name: deploymentCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := DeploymentConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+				}
+
+				deploymentCollector, err := NewDeployment(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return deploymentCollector, nil
+			},
 		}
 
-		loadBalancerCollector, err := NewLoadBalancer(c)
-		if err != nil {
-			return nil, microerror.Mask(err)
+		reloadables = append(reloadables, r)
+	}
+
+	{
+		r := &reloadableCollector{
+			name: loadBalancerCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID, LoadBalancerNames: s.LoadBalancerNames}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := LoadBalancerConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+					Names:      s.LoadBalancerNames,
+				}
+
+				loadBalancerCollector, err := NewLoadBalancer(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return loadBalancerCollector, nil
+			},
 		}
 
-		collectors = append(collectors, loadBalancerCollector)
+		reloadables = append(reloadables, r)
 	}
 
 	{
-		c := ResourceGroupConfig{
-			CtrlClient: config.K8sClient.CtrlClient(),
-			Logger:     config.Logger,
-			GSTenantID: config.GSTenantID,
+		r := &reloadableCollector{
+			name: platformMetricsCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := PlatformMetricsConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+				}
+
+				platformMetricsCollector, err := NewPlatformMetrics(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return platformMetricsCollector, nil
+			},
 		}
 
-		resourceGroupCollector, err := NewResourceGroup(c)
-		if err != nil {
-			return nil, microerror.Mask(err)
+		reloadables = append(reloadables, r)
+	}
+
+	{
+		r := &reloadableCollector{
+			name: resourceGroupCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID, ResourceGroupTagKeys: s.ResourceGroupTagKeys}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := ResourceGroupConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+					TagKeys:    s.ResourceGroupTagKeys,
+				}
+
+				resourceGroupCollector, err := NewResourceGroup(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return resourceGroupCollector, nil
+			},
 		}
 
-		collectors = append(collectors, resourceGroupCollector)
+		reloadables = append(reloadables, r)
 	}
 
 	{
-		c := UsageConfig{
-			CtrlClient: config.K8sClient.CtrlClient(),
-			Logger:     config.Logger,
-			Location:   config.Location,
-			GSTenantID: config.GSTenantID,
+		r := &reloadableCollector{
+			name: orphanedResourcesCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := OrphanedResourcesConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+				}
+
+				orphanedResourcesCollector, err := NewOrphanedResources(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return orphanedResourcesCollector, nil
+			},
 		}
 
-		usageCollector, err := NewUsage(c)
-		if err != nil {
-			return nil, microerror.Mask(err)
+		reloadables = append(reloadables, r)
+	}
+
+	{
+		r := &reloadableCollector{
+			name: usageCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID, Location: s.Location, UsageLocations: s.UsageLocations}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := UsageConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					Location:   s.Location,
+					Locations:  s.UsageLocations,
+					GSTenantID: s.GSTenantID,
+				}
+
+				usageCollector, err := NewUsage(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return usageCollector, nil
+			},
 		}
 
-		collectors = append(collectors, usageCollector)
+		reloadables = append(reloadables, r)
 	}
 
 	{
-		c := RateLimitConfig{
-			CtrlClient: config.K8sClient.CtrlClient(),
-			Location:   config.Location,
-			Logger:     config.Logger,
-			GSTenantID: config.GSTenantID,
+		r := &reloadableCollector{
+			name: rateLimitCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID, Location: s.Location}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := RateLimitConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Location:   s.Location,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+				}
+
+				rateLimitCollector, err := NewRateLimit(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return rateLimitCollector, nil
+			},
 		}
 
-		rateLimitCollector, err := NewRateLimit(c)
-		if err != nil {
-			return nil, microerror.Mask(err)
+		reloadables = append(reloadables, r)
+	}
+
+	{
+		r := &reloadableCollector{
+			name: spExpirationCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := SPExpirationConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+				}
+
+				spExpirationCollector, err := NewSPExpiration(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return spExpirationCollector, nil
+			},
 		}
 
-		collectors = append(collectors, rateLimitCollector)
+		reloadables = append(reloadables, r)
 	}
 
 	{
-		if config.GSTenantID == gsTenantID {
-			c := SPExpirationConfig{
-				CtrlClient: config.K8sClient.CtrlClient(),
-				Logger:     config.Logger,
-				GSTenantID: config.GSTenantID,
-			}
+		r := &reloadableCollector{
+			name: vmssRateLimitCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := VMSSRateLimitConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					GSTenantID: s.GSTenantID,
+				}
+
+				vmssRateLimitCollector, err := NewVMSSRateLimit(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return vmssRateLimitCollector, nil
+			},
+		}
 
-			spExpirationCollector, err := NewSPExpiration(c)
-			if err != nil {
-				return nil, microerror.Mask(err)
-			}
+		reloadables = append(reloadables, r)
+	}
 
-			collectors = append(collectors, spExpirationCollector)
+	{
+		r := &reloadableCollector{
+			name: vpnConnectionCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{ControlPlaneResourceGroup: s.ControlPlaneResourceGroup, GSTenantID: s.GSTenantID}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := VPNConnectionConfig{
+					CtrlClient:       config.K8sClient.CtrlClient(),
+					InstallationName: s.ControlPlaneResourceGroup,
+					Inventory:        config.Inventory,
+					Logger:           config.Logger,
+					Shard:            config.Shard,
+					GSTenantID:       s.GSTenantID,
+				}
+
+				vpnConnectionCollector, err := NewVPNConnection(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return vpnConnectionCollector, nil
+			},
 		}
+
+		reloadables = append(reloadables, r)
 	}
 
+	// Discovering clusters only talks to Kubernetes, so every replica keeps
+	// its inventory up to date even when it is not the leader.
+	var clusterInventoryCollector *reloadableCollector
 	{
-		c := VMSSRateLimitConfig{
-			CtrlClient: config.K8sClient.CtrlClient(),
-			Logger:     config.Logger,
-			GSTenantID: config.GSTenantID,
+		clusterInventoryCollector = &reloadableCollector{
+			name: "cluster_inventory",
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID, Location: s.Location}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := ClusterInventoryConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Inventory:  config.Inventory,
+					Logger:     config.Logger,
+					Location:   s.Location,
+					GSTenantID: s.GSTenantID,
+				}
+
+				clusterInventory, err := NewClusterInventory(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return clusterInventory, nil
+			},
 		}
+	}
 
-		vmssRateLimitCollector, err := NewVMSSRateLimit(c)
-		if err != nil {
-			return nil, microerror.Mask(err)
+	{
+		r := &reloadableCollector{
+			name: vmSKUAvailabilityCollectorName,
+			uses: func(s Settings) Settings {
+				return Settings{GSTenantID: s.GSTenantID, Location: s.Location}
+			},
+			create: func(s Settings) (collector.Interface, error) {
+				c := VMSKUAvailabilityConfig{
+					CtrlClient: config.K8sClient.CtrlClient(),
+					Logger:     config.Logger,
+					Shard:      config.Shard,
+					SKUCache:   skuCache,
+
+					Location:   s.Location,
+					GSTenantID: s.GSTenantID,
+				}
+
+				vmSKUAvailabilityCollector, err := NewVMSKUAvailability(c)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+
+				return vmSKUAvailabilityCollector, nil
+			},
 		}
 
-		collectors = append(collectors, vmssRateLimitCollector)
+		reloadables = append(reloadables, r)
 	}
 
-	{
-		c := VPNConnectionConfig{
-			CtrlClient:       config.K8sClient.CtrlClient(),
-			InstallationName: config.ControlPlaneResourceGroup,
-			Logger:           config.Logger,
-			GSTenantID:       config.GSTenantID,
+	for _, r := range reloadables {
+		collectors = append(collectors, r)
+	}
+
+	configState := &configState{
+		settings: Settings{
+			ControlPlaneResourceGroup: config.ControlPlaneResourceGroup,
+			GSTenantID:                config.GSTenantID,
+			Location:                  config.Location,
+			DisabledCollectors:        config.DisabledCollectors,
+			LoadBalancerNames:         config.LoadBalancerNames,
+			ResourceGroupTagKeys:      config.ResourceGroupTagKeys,
+			UsageLocations:            config.UsageLocations,
+		},
+		generation: 1,
+		reloadedAt: time.Now(),
+	}
+
+	for i, c := range collectors {
+		if config.Leader != nil {
+			c = &leaderCollector{
+				namedCollector: c,
+				leader:         config.Leader,
+			}
+		}
+
+		collectors[i] = &toggledCollector{
+			namedCollector: c,
+			config:         configState,
 		}
+	}
+
+	// The cluster collectors reading CRs only run on every replica, so that
+	// standbys keep exposing their metrics.
+	collectors = append(collectors, &toggledCollector{
+		namedCollector: clusterCollectors,
+		config:         configState,
+	})
 
-		vpnConnectionCollector, err := NewVPNConnection(c)
+	// The cluster inventory is never disabled, since readiness depends on it.
+	reloadables = append(reloadables, clusterInventoryCollector)
+	collectors = append(collectors, clusterInventoryCollector)
+
+	for _, r := range reloadables {
+		_, err := r.reload(configState.settings)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
+	}
+	collectors = append(collectors, configState)
 
-		collectors = append(collectors, vpnConnectionCollector)
+	state := newCollectionState()
+	var instrumented []collector.Interface
+	{
+		for _, c := range collectors {
+			instrumented = append(instrumented, &instrumentedCollector{
+				Interface: c,
+				name:      c.Name(),
+				state:     state,
+			})
+		}
 	}
 
 	var collectorSet *collector.Set
 	{
 		c := collector.SetConfig{
-			Collectors: collectors,
+			Collectors: instrumented,
 			Logger:     config.Logger,
 		}
 
@@ -207,6 +617,12 @@ func NewSet(config SetConfig) (*Set, error) {
 
 	s := &Set{
 		Set: collectorSet,
+
+		config:      configState,
+		inventory:   config.Inventory,
+		logger:      config.Logger,
+		reloadables: reloadables,
+		state:       state,
 	}
 
 	return s, nil
@@ -0,0 +1,69 @@
+package sku
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/compute/mgmt/compute"
+	"github.com/giantswarm/microerror"
+)
+
+// Cache keeps the VM sizes listed by ListVMs per subscription and location for
+// the given TTL, so that collectors needing them share a single, rarely
+// repeated call to the Resource SKUs API. It is safe for concurrent use. The
+// returned maps are shared and must not be modified.
+type Cache struct {
+	list func(ctx context.Context, skusClient compute.ResourceSkusClient, location string) (map[string]VM, error)
+	ttl  time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	vms      map[string]VM
+	listedAt time.Time
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	c := &Cache{
+		list: ListVMs,
+		ttl:  ttl,
+
+		mutex:   sync.Mutex{},
+		entries: map[string]cacheEntry{},
+	}
+
+	return c
+}
+
+// ListVMs works like the package level ListVMs but only calls the Azure API
+// when the VM sizes of the subscription of the given client and the given
+// location are not cached or expired.
+func (c *Cache) ListVMs(ctx context.Context, skusClient compute.ResourceSkusClient, location string) (map[string]VM, error) {
+	key := strings.ToLower(skusClient.SubscriptionID + "/" + location)
+
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	c.mutex.Unlock()
+
+	if ok && time.Since(entry.listedAt) < c.ttl {
+		return entry.vms, nil
+	}
+
+	vms, err := c.list(ctx, skusClient, location)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	c.mutex.Lock()
+	c.entries[key] = cacheEntry{
+		vms:      vms,
+		listedAt: time.Now(),
+	}
+	c.mutex.Unlock()
+
+	return vms, nil
+}
@@ -0,0 +1,57 @@
+package sku
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/compute/mgmt/compute"
+)
+
+func Test_Cache_ListVMs(t *testing.T) {
+	var calls []string
+	c := NewCache(time.Hour)
+	c.list = func(ctx context.Context, skusClient compute.ResourceSkusClient, location string) (map[string]VM, error) {
+		calls = append(calls, skusClient.SubscriptionID+"/"+location)
+		return map[string]VM{"standard_d4s_v3": {Name: "Standard_D4s_v3"}}, nil
+	}
+
+	ctx := context.Background()
+	subA := compute.NewResourceSkusClient("sub-a")
+	subB := compute.NewResourceSkusClient("sub-b")
+
+	for _, l := range []struct {
+		client   compute.ResourceSkusClient
+		location string
+	}{
+		{client: subA, location: "westeurope"},
+		{client: subA, location: "westeurope"},
+		{client: subA, location: "germanywestcentral"},
+		{client: subB, location: "westeurope"},
+	} {
+		vms, err := c.ListVMs(ctx, l.client, l.location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := vms["standard_d4s_v3"]; !ok {
+			t.Fatalf("expected cached VM sizes to be returned")
+		}
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 calls to the API got %v", calls)
+	}
+
+	c.entries["sub-a/westeurope"] = cacheEntry{
+		vms:      c.entries["sub-a/westeurope"].vms,
+		listedAt: time.Now().Add(-2 * time.Hour),
+	}
+
+	_, err := c.ListVMs(ctx, subA, "westeurope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("expected expired entry to be listed again got %v", calls)
+	}
+}
@@ -0,0 +1,142 @@
+// Package sku reads the virtual machine SKUs available in an Azure location.
+package sku
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/compute/mgmt/compute"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	capabilityVCPUs = "vCPUs"
+	typeVM          = "virtualMachines"
+)
+
+// VM describes a virtual machine size in a single location.
+type VM struct {
+	Name   string
+	Family string
+	VCPUs  int64
+	// Zones are the availability zones supporting the VM size in the location,
+	// including zones in which it is restricted.
+	Zones []string
+	// Restrictions prevent using the VM size in the location or in some of its
+	// zones.
+	Restrictions []Restriction
+}
+
+type Restriction struct {
+	// Type is either "Location" or "Zone".
+	Type string
+	// ReasonCode is either "QuotaId" or "NotAvailableForSubscription".
+	ReasonCode string
+	// Zones are the restricted zones for restrictions of type "Zone".
+	Zones []string
+}
+
+// Restricted returns whether the VM size cannot be used in the location at
+// all.
+func (v VM) Restricted() bool {
+	for _, r := range v.Restrictions {
+		if r.Type == string(compute.Location) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ZoneRestricted returns whether the VM size cannot be used in the given zone.
+func (v VM) ZoneRestricted(zone string) bool {
+	for _, r := range v.Restrictions {
+		if r.Type != string(compute.Zone) {
+			continue
+		}
+
+		for _, z := range r.Zones {
+			if z == zone {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ListVMs returns the virtual machine sizes in the given location keyed by the
+// lower case VM size, e.g. "standard_d4s_v3".
+func ListVMs(ctx context.Context, skusClient compute.ResourceSkusClient, location string) (map[string]VM, error) {
+	vms := map[string]VM{}
+
+	r, err := skusClient.List(ctx, fmt.Sprintf("location eq '%s'", location), "")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for r.NotDone() {
+		for _, v := range r.Values() {
+			if v.ResourceType == nil || *v.ResourceType != typeVM || v.Name == nil {
+				continue
+			}
+
+			vm := VM{
+				Name: *v.Name,
+			}
+			if v.Family != nil {
+				vm.Family = strings.ToLower(*v.Family)
+			}
+
+			if v.Capabilities != nil {
+				for _, c := range *v.Capabilities {
+					if c.Name == nil || *c.Name != capabilityVCPUs || c.Value == nil {
+						continue
+					}
+
+					vCPUs, err := strconv.ParseInt(*c.Value, 10, 64)
+					if err == nil {
+						vm.VCPUs = vCPUs
+					}
+				}
+			}
+
+			if v.LocationInfo != nil {
+				for _, l := range *v.LocationInfo {
+					if l.Location == nil || !strings.EqualFold(*l.Location, location) || l.Zones == nil {
+						continue
+					}
+
+					vm.Zones = append(vm.Zones, *l.Zones...)
+				}
+				sort.Strings(vm.Zones)
+			}
+
+			if v.Restrictions != nil {
+				for _, rs := range *v.Restrictions {
+					restriction := Restriction{
+						Type:       string(rs.Type),
+						ReasonCode: string(rs.ReasonCode),
+					}
+					if rs.RestrictionInfo != nil && rs.RestrictionInfo.Zones != nil {
+						restriction.Zones = *rs.RestrictionInfo.Zones
+					}
+
+					vm.Restrictions = append(vm.Restrictions, restriction)
+				}
+			}
+
+			vms[strings.ToLower(vm.Name)] = vm
+		}
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return vms, nil
+}
@@ -0,0 +1,74 @@
+package sku
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_VM_Restrictions(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		vm                     VM
+		zone                   string
+		expectedRestricted     bool
+		expectedZoneRestricted bool
+	}{
+		{
+			name: "case 0: no restrictions",
+			vm: VM{
+				Zones: []string{"1", "2", "3"},
+			},
+			zone:                   "1",
+			expectedRestricted:     false,
+			expectedZoneRestricted: false,
+		},
+		{
+			name: "case 1: restricted in the location",
+			vm: VM{
+				Restrictions: []Restriction{
+					{Type: "Location", ReasonCode: "NotAvailableForSubscription"},
+				},
+			},
+			zone:                   "1",
+			expectedRestricted:     true,
+			expectedZoneRestricted: false,
+		},
+		{
+			name: "case 2: restricted in another zone",
+			vm: VM{
+				Zones: []string{"1", "2", "3"},
+				Restrictions: []Restriction{
+					{Type: "Zone", ReasonCode: "NotAvailableForSubscription", Zones: []string{"2"}},
+				},
+			},
+			zone:                   "1",
+			expectedRestricted:     false,
+			expectedZoneRestricted: false,
+		},
+		{
+			name: "case 3: restricted in the zone",
+			vm: VM{
+				Zones: []string{"1", "2", "3"},
+				Restrictions: []Restriction{
+					{Type: "Zone", ReasonCode: "NotAvailableForSubscription", Zones: []string{"1", "2"}},
+				},
+			},
+			zone:                   "1",
+			expectedRestricted:     false,
+			expectedZoneRestricted: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			if tc.vm.Restricted() != tc.expectedRestricted {
+				t.Fatalf("expected restricted %t got %t", tc.expectedRestricted, tc.vm.Restricted())
+			}
+			if tc.vm.ZoneRestricted(tc.zone) != tc.expectedZoneRestricted {
+				t.Fatalf("expected zone restricted %t got %t", tc.expectedZoneRestricted, tc.vm.ZoneRestricted(tc.zone))
+			}
+		})
+	}
+}
@@ -11,9 +11,13 @@ import (
 
 	"github.com/giantswarm/azure-collector/v3/client"
 	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
 )
 
 const (
+	spExpirationCollectorName = "sp_expiration"
+
 	labelClientId        = "client_id"
 	labelSubscriptionId  = "subscription_id"
 	labelTenantId        = "tenant_id"
@@ -51,13 +55,17 @@ var (
 
 type SPExpirationConfig struct {
 	CtrlClient ctrlclient.Client
+	Inventory  *inventory.Inventory
 	Logger     micrologger.Logger
+	Shard      sharding.Interface
 	GSTenantID string
 }
 
 type SPExpiration struct {
 	ctrlClient ctrlclient.Client
+	inventory  *inventory.Inventory
 	logger     micrologger.Logger
+	shard      sharding.Interface
 	gsTenantID string
 }
 
@@ -67,16 +75,24 @@ func NewSPExpiration(config SPExpirationConfig) (*SPExpiration, error) {
 	if config.CtrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
 	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
 	if config.GSTenantID == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
 	}
 
 	v := &SPExpiration{
 		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
 		logger:     config.Logger,
+		shard:      config.Shard,
 		gsTenantID: config.GSTenantID,
 	}
 
@@ -86,6 +102,16 @@ func NewSPExpiration(config SPExpirationConfig) (*SPExpiration, error) {
 func (v *SPExpiration) Collect(ch chan<- prometheus.Metric) error {
 	ctx := context.Background()
 
+	// Service principals are only tracked in the Giant Swarm tenant.
+	if v.gsTenantID != gsTenantID {
+		return nil
+	}
+
+	// All service principals are listed at once, so only one replica collects them.
+	if !v.shard.Owns(v.gsTenantID) {
+		return nil
+	}
+
 	azureClientSets, err := credential.GetAzureClientSetsFromCredentialSecrets(ctx, v.ctrlClient, v.gsTenantID)
 	if err != nil {
 		return microerror.Mask(err)
@@ -105,6 +131,7 @@ func (v *SPExpiration) Collect(ch chan<- prometheus.Metric) error {
 			// Ignore but log
 			v.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Unable to list applications using client %#q", azureClientSetConfig.ClientID), "stack", microerror.JSON(err), "gsTenantID", v.gsTenantID)
 			failedScrapes[azureClientSetConfig.ClientID] = azureClientSetConfig
+			v.inventory.RecordSubscription(azureClientSetConfig.SubscriptionID, spExpirationCollectorName, err)
 			continue
 		}
 
@@ -125,10 +152,13 @@ func (v *SPExpiration) Collect(ch chan<- prometheus.Metric) error {
 			}
 
 			if err := apps.NextWithContext(ctx); err != nil {
+				v.inventory.RecordSubscription(azureClientSetConfig.SubscriptionID, spExpirationCollectorName, err)
 				return microerror.Mask(err)
 			}
 		}
 
+		v.inventory.RecordSubscription(azureClientSetConfig.SubscriptionID, spExpirationCollectorName, nil)
+
 		// We just need to list service principals once, so we can leave the loop.
 		break
 	}
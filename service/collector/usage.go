@@ -3,12 +3,24 @@ package collector
 import (
 	"context"
 
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/giantswarm/azure-collector/v3/client"
 	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
+)
+
+const (
+	usageCollectorName = "usage"
+
+	usageProviderCompute = "Microsoft.Compute"
+	usageProviderNetwork = "Microsoft.Network"
+	usageProviderStorage = "Microsoft.Storage"
 )
 
 var (
@@ -18,6 +30,8 @@ var (
 		[]string{
 			"name",
 			"subscription",
+			"location",
+			"provider",
 		},
 		nil,
 	)
@@ -27,30 +41,54 @@ var (
 		[]string{
 			"name",
 			"subscription",
+			"location",
+			"provider",
+		},
+		nil,
+	)
+	usageInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "usage", "info"),
+		"Localized display name of specific Quotas as defined by Azure. The value is always 1.",
+		[]string{
+			"name",
+			"subscription",
+			"location",
+			"provider",
+			"localized_name",
 		},
 		nil,
 	)
 	scrapeErrorCounter = prometheus.NewCounter(
 		prometheus.CounterOpts{Namespace: MetricsNamespace, Subsystem: "usage", Name: "scrape_error",
-			Help: "Total number of times compute resource usage information scraping returned an error.",
+			Help: "Total number of times resource usage information scraping returned an error.",
 		})
 )
 
 type UsageConfig struct {
-	CtrlClient client.Client
+	CtrlClient ctrlclient.Client
+	Inventory  *inventory.Inventory
 	Logger     micrologger.Logger
+	Shard      sharding.Interface
 
-	Location   string
+	// Location is used for subscriptions without any known cluster.
+	Location string
+	// Locations is optional. When set, usages are collected for these
+	// locations in every subscription instead of the locations of the clusters
+	// running in it.
+	Locations  []string
 	GSTenantID string
 }
 
 type Usage struct {
-	ctrlClient client.Client
+	ctrlClient ctrlclient.Client
+	inventory  *inventory.Inventory
 	logger     micrologger.Logger
+	shard      sharding.Interface
 
 	usageScrapeError prometheus.Counter
 
 	location   string
+	locations  []string
 	gsTenantID string
 }
 
@@ -64,9 +102,15 @@ func NewUsage(config UsageConfig) (*Usage, error) {
 	if config.CtrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
 	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
 
 	if config.Location == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Location must not be empty", config)
@@ -77,9 +121,12 @@ func NewUsage(config UsageConfig) (*Usage, error) {
 
 	u := &Usage{
 		ctrlClient:       config.CtrlClient,
+		inventory:        config.Inventory,
 		logger:           config.Logger,
+		shard:            config.Shard,
 		usageScrapeError: scrapeErrorCounter,
 		location:         config.Location,
+		locations:        normalizeLocations(config.Locations),
 		gsTenantID:       config.GSTenantID,
 	}
 
@@ -93,45 +140,215 @@ func (u *Usage) Collect(ch chan<- prometheus.Metric) error {
 		return microerror.Mask(err)
 	}
 
+	clusterLocations := u.inventory.Locations()
+
 	// We track usage metrics for each client labeled by subscription.
-	// That way we prevent duplicated metrics.
+	// That way we prevent duplicated metrics. A failing subscription does not
+	// stop the others from being collected, the first error is returned once
+	// all of them are done.
+	var collectErr error
 	for subscriptionID, azureClientSet := range clientSets {
-		r, err := azureClientSet.UsageClient.List(ctx, u.location)
+		if !u.shard.Owns(subscriptionID) {
+			continue
+		}
+
+		var err error
+		for _, location := range u.subscriptionLocations(subscriptionID, clusterLocations) {
+			locationErr := u.collectForLocation(ctx, ch, subscriptionID, location, azureClientSet)
+			if err == nil {
+				err = locationErr
+			}
+		}
+
+		u.inventory.RecordSubscription(subscriptionID, usageCollectorName, err)
+		if collectErr == nil {
+			collectErr = err
+		}
+	}
+
+	if collectErr != nil {
+		return microerror.Mask(collectErr)
+	}
+
+	return nil
+}
+
+// subscriptionLocations returns the locations to collect usages for in the
+// given subscription.
+func (u *Usage) subscriptionLocations(subscriptionID string, clusterLocations map[string][]string) []string {
+	if len(u.locations) > 0 {
+		return u.locations
+	}
+
+	locations := []string{inventory.NormalizeLocation(u.location)}
+	for _, l := range clusterLocations[subscriptionID] {
+		if l != locations[0] {
+			locations = append(locations, l)
+		}
+	}
+
+	return locations
+}
+
+// normalizeLocations returns the given locations in the form used by the
+// Azure APIs, e.g. "westeurope" for "West Europe", without duplicates.
+func normalizeLocations(locations []string) []string {
+	var normalized []string
+	seen := map[string]bool{}
+	for _, l := range locations {
+		n := inventory.NormalizeLocation(l)
+		if n == "" || seen[n] {
+			continue
+		}
+
+		seen[n] = true
+		normalized = append(normalized, n)
+	}
+
+	return normalized
+}
+
+// collectForLocation collects the usages of all providers in the given
+// location. A failing provider does not stop the others from being collected,
+// the first error is returned once all of them are done.
+func (u *Usage) collectForLocation(ctx context.Context, ch chan<- prometheus.Metric, subscriptionID, location string, azureClientSet *client.AzureClientSet) error {
+	var collectErr error
+	for _, collect := range []func(context.Context, chan<- prometheus.Metric, string, string, *client.AzureClientSet) error{
+		u.collectCompute,
+		u.collectNetwork,
+		u.collectStorage,
+	} {
+		err := collect(ctx, ch, subscriptionID, location, azureClientSet)
 		if err != nil {
-			u.logger.Errorf(ctx, err, "an error occurred during the scraping of current compute resource usage information")
 			u.usageScrapeError.Inc()
-		} else {
-			for r.NotDone() {
-				for _, v := range r.Values() {
-					ch <- prometheus.MustNewConstMetric(
-						usageCurrentDesc,
-						prometheus.GaugeValue,
-						float64(*v.CurrentValue),
-						*v.Name.LocalizedValue,
-						subscriptionID,
-					)
-					ch <- prometheus.MustNewConstMetric(
-						usageLimitDesc,
-						prometheus.GaugeValue,
-						float64(*v.Limit),
-						*v.Name.LocalizedValue,
-						subscriptionID,
-					)
-				}
-
-				err := r.NextWithContext(ctx)
-				if err != nil {
-					return microerror.Mask(err)
-				}
+			if collectErr == nil {
+				collectErr = err
+			}
+		}
+	}
+
+	if collectErr != nil {
+		return microerror.Mask(collectErr)
+	}
+
+	return nil
+}
+
+func (u *Usage) collectCompute(ctx context.Context, ch chan<- prometheus.Metric, subscriptionID, location string, azureClientSet *client.AzureClientSet) error {
+	r, err := azureClientSet.UsageClient.List(ctx, location)
+	if err != nil {
+		u.logger.Errorf(ctx, err, "an error occurred during the scraping of current compute resource usage information in location %#q", location)
+		return microerror.Mask(err)
+	}
+
+	for r.NotDone() {
+		for _, v := range r.Values() {
+			if v.Name == nil || v.CurrentValue == nil || v.Limit == nil {
+				continue
 			}
+
+			u.emit(ch, v.Name.Value, v.Name.LocalizedValue, subscriptionID, location, usageProviderCompute, float64(*v.CurrentValue), float64(*v.Limit))
+		}
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			return microerror.Mask(err)
 		}
 	}
 
 	return nil
 }
 
+func (u *Usage) collectNetwork(ctx context.Context, ch chan<- prometheus.Metric, subscriptionID, location string, azureClientSet *client.AzureClientSet) error {
+	r, err := azureClientSet.NetworkUsagesClient.List(ctx, location)
+	if err != nil {
+		u.logger.Errorf(ctx, err, "an error occurred during the scraping of current network resource usage information in location %#q", location)
+		return microerror.Mask(err)
+	}
+
+	for r.NotDone() {
+		for _, v := range r.Values() {
+			if v.Name == nil || v.CurrentValue == nil || v.Limit == nil {
+				continue
+			}
+
+			u.emit(ch, v.Name.Value, v.Name.LocalizedValue, subscriptionID, location, usageProviderNetwork, float64(*v.CurrentValue), float64(*v.Limit))
+		}
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (u *Usage) collectStorage(ctx context.Context, ch chan<- prometheus.Metric, subscriptionID, location string, azureClientSet *client.AzureClientSet) error {
+	r, err := azureClientSet.StorageUsagesClient.ListByLocation(ctx, location)
+	if err != nil {
+		u.logger.Errorf(ctx, err, "an error occurred during the scraping of current storage resource usage information in location %#q", location)
+		return microerror.Mask(err)
+	}
+
+	if r.Value == nil {
+		return nil
+	}
+
+	for _, v := range *r.Value {
+		if v.Name == nil || v.CurrentValue == nil || v.Limit == nil {
+			continue
+		}
+
+		u.emit(ch, v.Name.Value, v.Name.LocalizedValue, subscriptionID, location, usageProviderStorage, float64(*v.CurrentValue), float64(*v.Limit))
+	}
+
+	return nil
+}
+
+// emit sends the usage metrics labeled by the stable name of the quota, e.g.
+// "standardDSv3Family". The localized display name, e.g. "Standard DSv3
+// Family vCPUs", changes with the wording and locale of Azure, so it is only
+// exposed as an info metric.
+func (u *Usage) emit(ch chan<- prometheus.Metric, value, localizedValue *string, subscriptionID, location, provider string, current, limit float64) {
+	if value == nil || *value == "" {
+		return
+	}
+	name := *value
+
+	ch <- prometheus.MustNewConstMetric(
+		usageCurrentDesc,
+		prometheus.GaugeValue,
+		current,
+		name,
+		subscriptionID,
+		location,
+		provider,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		usageLimitDesc,
+		prometheus.GaugeValue,
+		limit,
+		name,
+		subscriptionID,
+		location,
+		provider,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		usageInfoDesc,
+		prometheus.GaugeValue,
+		1,
+		name,
+		subscriptionID,
+		location,
+		provider,
+		to.String(localizedValue),
+	)
+}
+
 func (u *Usage) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- usageCurrentDesc
 	ch <- usageLimitDesc
+	ch <- usageInfoDesc
 	return nil
 }
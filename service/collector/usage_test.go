@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_Usage_emit(t *testing.T) {
+	testCases := []struct {
+		name           string
+		value          *string
+		localizedValue *string
+		expectedLabels map[string]string
+	}{
+		{
+			name:           "case 0: stable name is used as name label",
+			value:          to.StringPtr("standardDSv3Family"),
+			localizedValue: to.StringPtr("Standard DSv3 Family vCPUs"),
+			expectedLabels: map[string]string{
+				"name":           "standardDSv3Family",
+				"localized_name": "Standard DSv3 Family vCPUs",
+			},
+		},
+		{
+			name:           "case 1: missing localized name is empty",
+			value:          to.StringPtr("cores"),
+			expectedLabels: map[string]string{"name": "cores", "localized_name": ""},
+		},
+		{
+			name:           "case 2: missing stable name is skipped",
+			localizedValue: to.StringPtr("Total Regional vCPUs"),
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			ch := make(chan prometheus.Metric, 10)
+			u := &Usage{}
+			u.emit(ch, tc.value, tc.localizedValue, "sub", "westeurope", usageProviderCompute, 1, 10)
+			close(ch)
+
+			labels := map[string]string{}
+			var count int
+			for m := range ch {
+				count++
+
+				var metric dto.Metric
+				err := m.Write(&metric)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, l := range metric.Label {
+					labels[l.GetName()] = l.GetValue()
+				}
+			}
+
+			if tc.expectedLabels == nil {
+				if count != 0 {
+					t.Fatalf("expected no metrics got %d", count)
+				}
+				return
+			}
+
+			if count != 3 {
+				t.Fatalf("expected 3 metrics got %d", count)
+			}
+			for k, v := range tc.expectedLabels {
+				if labels[k] != v {
+					t.Fatalf("expected label %#q to be %#q got %#q", k, v, labels[k])
+				}
+			}
+		})
+	}
+}
+
+func Test_Usage_subscriptionLocations(t *testing.T) {
+	testCases := []struct {
+		name              string
+		locations         []string
+		clusterLocations  map[string][]string
+		expectedLocations []string
+	}{
+		{
+			name:              "case 0: default location and cluster locations",
+			clusterLocations:  map[string][]string{"sub": {"germanywestcentral", "westeurope"}},
+			expectedLocations: []string{"westeurope", "germanywestcentral"},
+		},
+		{
+			name:              "case 1: configured locations are normalized and deduplicated",
+			locations:         []string{"West Europe", "westeurope", "", "Germany West Central"},
+			clusterLocations:  map[string][]string{"sub": {"northeurope"}},
+			expectedLocations: []string{"westeurope", "germanywestcentral"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			u := &Usage{
+				location:  "West Europe",
+				locations: normalizeLocations(tc.locations),
+			}
+
+			locations := u.subscriptionLocations("sub", tc.clusterLocations)
+
+			if !cmp.Equal(locations, tc.expectedLocations) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedLocations, locations))
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package collector
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/compute/mgmt/compute"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest/to"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capzv1beta1 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capzexpv1beta1 "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1beta1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/azure-collector/v3/internal/capzcredentials"
+	"github.com/giantswarm/azure-collector/v3/service/collector/key"
+	"github.com/giantswarm/azure-collector/v3/service/collector/sku"
+	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
+)
+
+const (
+	vmSKUAvailabilityCollectorName = "vm_sku_availability"
+
+	vmSkuSubsystem = "vm_sku"
+
+	labelVMSize     = "vm_size"
+	labelZone       = "zone"
+	labelType       = "type"
+	labelReasonCode = "reason_code"
+)
+
+var (
+	vmSkuAvailableDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, vmSkuSubsystem, "available"),
+		"Whether a VM size used by a cluster can be created in the location of the cluster.",
+		[]string{
+			"subscription",
+			"location",
+			labelVMSize,
+		},
+		nil,
+	)
+	vmSkuZoneAvailableDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, vmSkuSubsystem, "zone_available"),
+		"Whether a VM size used by a cluster can be created in an availability zone of the location of the cluster.",
+		[]string{
+			"subscription",
+			"location",
+			labelVMSize,
+			labelZone,
+		},
+		nil,
+	)
+	vmSkuRestrictionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, vmSkuSubsystem, "restriction"),
+		"Restriction preventing a VM size used by a cluster from being created in the location of the cluster or some of its zones.",
+		[]string{
+			"subscription",
+			"location",
+			labelVMSize,
+			labelType,
+			labelReasonCode,
+		},
+		nil,
+	)
+)
+
+type VMSKUAvailabilityConfig struct {
+	CtrlClient client.Client
+	Logger     micrologger.Logger
+	Shard      sharding.Interface
+	SKUCache   *sku.Cache
+
+	Location   string
+	GSTenantID string
+}
+
+type VMSKUAvailability struct {
+	ctrlClient client.Client
+	logger     micrologger.Logger
+	shard      sharding.Interface
+	skuCache   *sku.Cache
+
+	location   string
+	gsTenantID string
+}
+
+// NewVMSKUAvailability exposes whether the VM sizes used by the vintage and CAPI clusters can be created.
+// The sizes come from the cluster CRs and from the scale sets actually running in the cluster resource groups.
+// It uses the Resource SKUs API, which reports sizes that are not available for the subscription or restricted in some zones.
+func NewVMSKUAvailability(config VMSKUAvailabilityConfig) (*VMSKUAvailability, error) {
+	if config.CtrlClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
+	if config.SKUCache == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SKUCache must not be empty", config)
+	}
+	if config.Location == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Location must not be empty", config)
+	}
+	if config.GSTenantID == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
+	}
+
+	v := &VMSKUAvailability{
+		ctrlClient: config.CtrlClient,
+		logger:     config.Logger,
+		shard:      config.Shard,
+		skuCache:   config.SKUCache,
+
+		location:   config.Location,
+		gsTenantID: config.GSTenantID,
+	}
+
+	return v, nil
+}
+
+// vmSizesInLocation groups the VM sizes used in a location of a subscription.
+type vmSizesInLocation struct {
+	subscriptionID string
+	location       string
+	skusClient     compute.ResourceSkusClient
+	vmSizes        map[string]bool
+}
+
+// vmSizeGroups collects VM sizes grouped by subscription and location, in
+// the order in which the groups are first seen.
+type vmSizeGroups struct {
+	groups  map[string]*vmSizesInLocation
+	ordered []*vmSizesInLocation
+}
+
+func newVMSizeGroups() *vmSizeGroups {
+	return &vmSizeGroups{
+		groups: map[string]*vmSizesInLocation{},
+	}
+}
+
+func (g *vmSizeGroups) add(subscriptionID, location, vmSize string, skusClient compute.ResourceSkusClient) {
+	if location == "" || vmSize == "" {
+		return
+	}
+
+	location = inventory.NormalizeLocation(location)
+	k := subscriptionID + "/" + location
+	group, ok := g.groups[k]
+	if !ok {
+		group = &vmSizesInLocation{
+			subscriptionID: subscriptionID,
+			location:       location,
+			skusClient:     skusClient,
+			vmSizes:        map[string]bool{},
+		}
+		g.groups[k] = group
+		g.ordered = append(g.ordered, group)
+	}
+
+	group.vmSizes[vmSize] = true
+}
+
+func (v *VMSKUAvailability) Collect(ch chan<- prometheus.Metric) error {
+	ctx := context.Background()
+
+	groups := newVMSizeGroups()
+
+	err := v.addVintageVMSizes(ctx, groups)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = v.addCAPIVMSizes(ctx, groups)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, g := range groups.ordered {
+		vms, err := v.skuCache.ListVMs(ctx, g.skusClient, g.location)
+		if err != nil {
+			v.logger.Errorf(ctx, err, "Unable to get VM SKUs in location %q for subscription %q", g.location, g.subscriptionID)
+			continue
+		}
+
+		var vmSizes []string
+		for s := range g.vmSizes {
+			vmSizes = append(vmSizes, s)
+		}
+		sort.Strings(vmSizes)
+
+		for _, vmSize := range vmSizes {
+			vm, ok := vms[strings.ToLower(vmSize)]
+
+			ch <- prometheus.MustNewConstMetric(
+				vmSkuAvailableDesc,
+				prometheus.GaugeValue,
+				boolToFloat64(ok && !vm.Restricted()),
+				g.subscriptionID,
+				g.location,
+				vmSize,
+			)
+
+			if !ok {
+				continue
+			}
+
+			for _, zone := range vm.Zones {
+				ch <- prometheus.MustNewConstMetric(
+					vmSkuZoneAvailableDesc,
+					prometheus.GaugeValue,
+					boolToFloat64(!vm.Restricted() && !vm.ZoneRestricted(zone)),
+					g.subscriptionID,
+					g.location,
+					vmSize,
+					zone,
+				)
+			}
+
+			emitted := map[string]bool{}
+			for _, r := range vm.Restrictions {
+				key := r.Type + "/" + r.ReasonCode
+				if emitted[key] {
+					continue
+				}
+				emitted[key] = true
+
+				ch <- prometheus.MustNewConstMetric(
+					vmSkuRestrictionDesc,
+					prometheus.GaugeValue,
+					1,
+					g.subscriptionID,
+					g.location,
+					vmSize,
+					r.Type,
+					r.ReasonCode,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *VMSKUAvailability) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- vmSkuAvailableDesc
+	ch <- vmSkuZoneAvailableDesc
+	ch <- vmSkuRestrictionDesc
+	return nil
+}
+
+// addVintageVMSizes adds the master and worker VM sizes of the AzureConfigs
+// and the sizes of the scale sets running in their resource groups. Vintage
+// clusters always run in the location of the installation.
+func (v *VMSKUAvailability) addVintageVMSizes(ctx context.Context, groups *vmSizeGroups) error {
+	var crs []providerv1alpha1.AzureConfig
+	{
+		mark := ""
+		page := 0
+		for page == 0 || len(mark) > 0 {
+			opts := client.ListOptions{
+				Continue: mark,
+			}
+			list := providerv1alpha1.AzureConfigList{}
+			err := v.ctrlClient.List(ctx, &list, &opts)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			crs = append(crs, list.Items...)
+
+			mark = list.Continue
+			page++
+		}
+	}
+
+	for _, cr := range crs {
+		config, err := credential.GetAzureConfigFromSecretName(ctx, v.ctrlClient, key.CredentialName(cr), key.CredentialNamespace(cr), v.gsTenantID)
+		if err != nil {
+			v.logger.Errorf(ctx, err, "Unable to get azure credentials for cluster %q", cr.Name)
+			continue
+		}
+
+		if !v.shard.Owns(config.SubscriptionID) {
+			continue
+		}
+
+		skusClient, vmssClient := newVMSKUClients(config.Authorizer, config.SubscriptionID)
+
+		for _, vmSize := range vintageVMSizes(cr) {
+			groups.add(config.SubscriptionID, v.location, vmSize, skusClient)
+		}
+
+		v.addScaleSetVMSizes(ctx, groups, vmssClient, skusClient, config.SubscriptionID, cr.Name)
+	}
+
+	return nil
+}
+
+// addCAPIVMSizes adds the VM sizes of the AzureMachinePools and
+// AzureMachineTemplates of all CAPI clusters and the sizes of the scale sets
+// running in their resource groups.
+func (v *VMSKUAvailability) addCAPIVMSizes(ctx context.Context, groups *vmSizeGroups) error {
+	clusters := &capiv1beta1.ClusterList{}
+	err := v.ctrlClient.List(ctx, clusters, client.InNamespace(metav1.NamespaceAll))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, cr := range clusters.Items {
+		azureCredentials, err := capzcredentials.GetAzureCredentialsFromMetadata(ctx, v.ctrlClient, cr.ObjectMeta)
+		if err != nil {
+			v.logger.Errorf(ctx, err, "Unable to get azure credentials for cluster %q", cr.Name)
+			continue
+		}
+
+		subscriptionID := azureCredentials.SubscriptionID
+		if !v.shard.Owns(subscriptionID) {
+			continue
+		}
+
+		var skusClient compute.ResourceSkusClient
+		var vmssClient compute.VirtualMachineScaleSetsClient
+		{
+			settings := auth.NewClientCredentialsConfig(azureCredentials.ClientID, azureCredentials.ClientSecret, azureCredentials.TenantID)
+			authorizer, err := settings.Authorizer()
+			if err != nil {
+				v.logger.Errorf(ctx, err, "Unable to use azure credentials for cluster %q", cr.Name)
+				continue
+			}
+
+			skusClient, vmssClient = newVMSKUClients(authorizer, subscriptionID)
+		}
+
+		resourceGroup := cr.Name
+		var clusterLocation string
+		if cr.Spec.InfrastructureRef != nil {
+			azureCluster := &capzv1beta1.AzureCluster{}
+			err = v.ctrlClient.Get(ctx, client.ObjectKey{Namespace: cr.Spec.InfrastructureRef.Namespace, Name: cr.Spec.InfrastructureRef.Name}, azureCluster)
+			if err != nil {
+				v.logger.Errorf(ctx, err, "Unable to get AzureCluster for cluster %q", cr.Name)
+			} else {
+				clusterLocation = azureCluster.Spec.Location
+				if azureCluster.Spec.ResourceGroup != "" {
+					resourceGroup = azureCluster.Spec.ResourceGroup
+				}
+			}
+		}
+
+		selector := client.MatchingLabels{capiv1beta1.ClusterLabelName: cr.Name}
+
+		amps := &capzexpv1beta1.AzureMachinePoolList{}
+		err = v.ctrlClient.List(ctx, amps, client.InNamespace(cr.Namespace), selector)
+		if err != nil {
+			v.logger.Errorf(ctx, err, "Unable to list AzureMachinePools for cluster %q", cr.Name)
+		} else {
+			for _, amp := range amps.Items {
+				location := amp.Spec.Location
+				if location == "" {
+					location = clusterLocation
+				}
+
+				groups.add(subscriptionID, location, amp.Spec.Template.VMSize, skusClient)
+			}
+		}
+
+		templates := &capzv1beta1.AzureMachineTemplateList{}
+		err = v.ctrlClient.List(ctx, templates, client.InNamespace(cr.Namespace), selector)
+		if err != nil {
+			v.logger.Errorf(ctx, err, "Unable to list AzureMachineTemplates for cluster %q", cr.Name)
+		} else {
+			for _, t := range templates.Items {
+				groups.add(subscriptionID, clusterLocation, t.Spec.Template.Spec.VMSize, skusClient)
+			}
+		}
+
+		v.addScaleSetVMSizes(ctx, groups, vmssClient, skusClient, subscriptionID, resourceGroup)
+	}
+
+	return nil
+}
+
+// addScaleSetVMSizes adds the sizes of the scale sets running in the given
+// resource group, which may differ from the CRs while an update is rolling out
+// or when a scale set was changed by hand.
+func (v *VMSKUAvailability) addScaleSetVMSizes(ctx context.Context, groups *vmSizeGroups, vmssClient compute.VirtualMachineScaleSetsClient, skusClient compute.ResourceSkusClient, subscriptionID, resourceGroup string) {
+	r, err := vmssClient.ListComplete(ctx, resourceGroup)
+	if IsNotFound(err) {
+		// Resource group might be missing, all good.
+		return
+	} else if err != nil {
+		v.logger.Errorf(ctx, err, "Unable to list scale sets in resource group %q", resourceGroup)
+		return
+	}
+
+	for r.NotDone() {
+		vmss := r.Value()
+		if vmss.Sku != nil {
+			groups.add(subscriptionID, to.String(vmss.Location), to.String(vmss.Sku.Name), skusClient)
+		}
+
+		err := r.NextWithContext(ctx)
+		if err != nil {
+			v.logger.Errorf(ctx, err, "Unable to list scale sets in resource group %q", resourceGroup)
+			return
+		}
+	}
+}
+
+func newVMSKUClients(authorizer autorest.Authorizer, subscriptionID string) (compute.ResourceSkusClient, compute.VirtualMachineScaleSetsClient) {
+	skusClient := compute.NewResourceSkusClient(subscriptionID)
+	skusClient.Client.Authorizer = authorizer
+
+	vmssClient := compute.NewVirtualMachineScaleSetsClient(subscriptionID)
+	vmssClient.Client.Authorizer = authorizer
+
+	return skusClient, vmssClient
+}
+
+// vintageVMSizes returns the master and worker VM sizes of an AzureConfig.
+func vintageVMSizes(cr providerv1alpha1.AzureConfig) []string {
+	var vmSizes []string
+	for _, n := range cr.Spec.Azure.Masters {
+		vmSizes = append(vmSizes, n.VMSize)
+	}
+	for _, n := range cr.Spec.Azure.Workers {
+		vmSizes = append(vmSizes, n.VMSize)
+	}
+
+	return vmSizes
+}
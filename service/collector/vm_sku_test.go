@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/compute/mgmt/compute"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_vmSizeGroups_add(t *testing.T) {
+	type vmSize struct {
+		subscriptionID string
+		location       string
+		vmSize         string
+	}
+
+	testCases := []struct {
+		name     string
+		vmSizes  []vmSize
+		expected map[string][]string
+	}{
+		{
+			name:     "case 0: no sizes",
+			expected: map[string][]string{},
+		},
+		{
+			name: "case 1: sizes are grouped by subscription and normalized location",
+			vmSizes: []vmSize{
+				{subscriptionID: "sub1", location: "West Europe", vmSize: "Standard_D4s_v3"},
+				{subscriptionID: "sub1", location: "westeurope", vmSize: "Standard_D4s_v3"},
+				{subscriptionID: "sub1", location: "westeurope", vmSize: "Standard_D8s_v3"},
+				{subscriptionID: "sub2", location: "westeurope", vmSize: "Standard_D4s_v3"},
+			},
+			expected: map[string][]string{
+				"sub1/westeurope": {"Standard_D4s_v3", "Standard_D8s_v3"},
+				"sub2/westeurope": {"Standard_D4s_v3"},
+			},
+		},
+		{
+			name: "case 2: sizes without location or size are ignored",
+			vmSizes: []vmSize{
+				{subscriptionID: "sub1", location: "", vmSize: "Standard_D4s_v3"},
+				{subscriptionID: "sub1", location: "westeurope", vmSize: ""},
+			},
+			expected: map[string][]string{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			groups := newVMSizeGroups()
+			for _, s := range tc.vmSizes {
+				groups.add(s.subscriptionID, s.location, s.vmSize, compute.ResourceSkusClient{})
+			}
+
+			result := map[string][]string{}
+			for _, g := range groups.ordered {
+				var vmSizes []string
+				for s := range g.vmSizes {
+					vmSizes = append(vmSizes, s)
+				}
+				sort.Strings(vmSizes)
+				result[g.subscriptionID+"/"+g.location] = vmSizes
+			}
+
+			if !cmp.Equal(result, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
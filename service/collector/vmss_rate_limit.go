@@ -23,9 +23,13 @@ import (
 	"github.com/giantswarm/azure-collector/v3/client"
 	"github.com/giantswarm/azure-collector/v3/service/collector/key"
 	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
 )
 
 const (
+	vmssRateLimitCollectorName = "vmss_rate_limit"
+
 	// Note that an API request can be subjected to multiple throttling policies.
 	// There will be a separate x-ms-ratelimit-remaining-resource header for each policy.
 	//
@@ -73,13 +77,17 @@ var (
 
 type VMSSRateLimitConfig struct {
 	CtrlClient ctrlclient.Client
+	Inventory  *inventory.Inventory
 	Logger     micrologger.Logger
+	Shard      sharding.Interface
 	GSTenantID string
 }
 
 type VMSSRateLimit struct {
 	ctrlClient ctrlclient.Client
+	inventory  *inventory.Inventory
 	logger     micrologger.Logger
+	shard      sharding.Interface
 	gsTenantID string
 }
 
@@ -91,16 +99,24 @@ func NewVMSSRateLimit(config VMSSRateLimitConfig) (*VMSSRateLimit, error) {
 	if config.CtrlClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.CtrlClient must not be empty", config)
 	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
 	if config.GSTenantID == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
 	}
 
 	u := &VMSSRateLimit{
 		ctrlClient: config.CtrlClient,
+		inventory:  config.Inventory,
 		logger:     config.Logger,
+		shard:      config.Shard,
 		gsTenantID: config.GSTenantID,
 	}
 
@@ -135,6 +151,10 @@ func (u *VMSSRateLimit) Collect(ch chan<- prometheus.Metric) error {
 			return microerror.Mask(err)
 		}
 
+		if !u.shard.Owns(config.SubscriptionID) {
+			continue
+		}
+
 		// We want to check only once per subscription
 		if inArray(doneSubscriptions, config.SubscriptionID) {
 			u.logger.Debugf(ctx, "Skipping Cluster %#q, its subscription was already collected", cluster)
@@ -151,8 +171,10 @@ func (u *VMSSRateLimit) Collect(ch chan<- prometheus.Metric) error {
 			u.collectMeasuredCallsFromResponse(ch, result, config.SubscriptionID, config.ClientID)
 		} else if err != nil {
 			u.logger.LogCtx(ctx, "level", "warning", "message", "Skipping", "clientid", config.ClientID, "subscriptionid", config.SubscriptionID, "tenantid", config.TenantID, "stack", microerror.JSON(err))
+			u.inventory.RecordSubscription(config.SubscriptionID, vmssRateLimitCollectorName, err)
 			continue
 		}
+		u.inventory.RecordSubscription(config.SubscriptionID, vmssRateLimitCollectorName, nil)
 
 		// Note that an API request can be subjected to multiple throttling policies.
 		// There will be a separate x-ms-ratelimit-remaining-resource header for each policy.
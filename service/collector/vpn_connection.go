@@ -8,9 +8,16 @@ import (
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/errgroup"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/giantswarm/azure-collector/v3/client"
 	"github.com/giantswarm/azure-collector/v3/service/credential"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
+)
+
+const (
+	vpnConnectionCollectorName = "vpn_connection"
 )
 
 var (
@@ -30,16 +37,20 @@ var (
 )
 
 type VPNConnectionConfig struct {
-	CtrlClient       client.Client
+	CtrlClient       ctrlclient.Client
 	InstallationName string
+	Inventory        *inventory.Inventory
 	Logger           micrologger.Logger
+	Shard            sharding.Interface
 	GSTenantID       string
 }
 
 type VPNConnection struct {
-	ctrlClient       client.Client
+	ctrlClient       ctrlclient.Client
 	installationName string
+	inventory        *inventory.Inventory
 	logger           micrologger.Logger
+	shard            sharding.Interface
 	gsTenantID       string
 }
 
@@ -50,9 +61,15 @@ func NewVPNConnection(config VPNConnectionConfig) (*VPNConnection, error) {
 	if config.InstallationName == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.InstallationName must not be empty", config)
 	}
+	if config.Inventory == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Inventory must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Shard == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Shard must not be empty", config)
+	}
 	if config.GSTenantID == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.GSTenantID must not be empty", config)
 	}
@@ -60,7 +77,9 @@ func NewVPNConnection(config VPNConnectionConfig) (*VPNConnection, error) {
 	v := &VPNConnection{
 		ctrlClient:       config.CtrlClient,
 		installationName: config.InstallationName,
+		inventory:        config.Inventory,
 		logger:           config.Logger,
+		shard:            config.Shard,
 		gsTenantID:       config.GSTenantID,
 	}
 
@@ -76,56 +95,70 @@ func (v *VPNConnection) Collect(ch chan<- prometheus.Metric) error {
 	}
 
 	for clusterID, azureClientSet := range azureClientSets {
-		connections, err := azureClientSet.VirtualNetworkGatewayConnectionsClient.ListComplete(ctx, clusterID)
+		if !v.shard.Owns(clusterID) {
+			continue
+		}
+
+		err := v.collectForCluster(ctx, ch, clusterID, azureClientSet)
+		v.inventory.Record(clusterID, vpnConnectionCollectorName, err)
 		if err != nil {
 			return microerror.Mask(err)
 		}
+	}
 
-		var g errgroup.Group
-
-		for connections.NotDone() {
-			c := connections.Value()
-			connectionName := to.String(c.Name)
-
-			// ConnectionStatus returned by the API when listing connections is always empty.
-			// Details for each connection must be requested in order to get a value for ConnectionStatus.
-			g.Go(func() error {
-				connection, err := azureClientSet.VirtualNetworkGatewayConnectionsClient.Get(ctx, clusterID, connectionName)
-				if err != nil {
-					return microerror.Mask(err)
-				}
-
-				// We ignore customer's VPN gateways by filtering the VPN gateway name.
-				// We use the installation name as the VPN gateway name.
-				if to.String(connection.ID) != v.installationName {
-					return nil
-				}
-
-				ch <- prometheus.MustNewConstMetric(
-					vpnConnectionDesc,
-					prometheus.GaugeValue,
-					1,
-					to.String(connection.ID),
-					connectionName,
-					to.String(connection.Location),
-					string(connection.ConnectionType),
-					string(connection.ConnectionStatus),
-					string(connection.ProvisioningState),
-				)
+	return nil
+}
 
-				return nil
-			})
+func (v *VPNConnection) collectForCluster(ctx context.Context, ch chan<- prometheus.Metric, clusterID string, azureClientSet *client.AzureClientSet) error {
+	connections, err := azureClientSet.VirtualNetworkGatewayConnectionsClient.ListComplete(ctx, clusterID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var g errgroup.Group
 
-			if err := connections.NextWithContext(ctx); err != nil {
+	for connections.NotDone() {
+		c := connections.Value()
+		connectionName := to.String(c.Name)
+
+		// ConnectionStatus returned by the API when listing connections is always empty.
+		// Details for each connection must be requested in order to get a value for ConnectionStatus.
+		g.Go(func() error {
+			connection, err := azureClientSet.VirtualNetworkGatewayConnectionsClient.Get(ctx, clusterID, connectionName)
+			if err != nil {
 				return microerror.Mask(err)
 			}
-		}
 
-		if err := g.Wait(); err != nil {
+			// We ignore customer's VPN gateways by filtering the VPN gateway name.
+			// We use the installation name as the VPN gateway name.
+			if to.String(connection.ID) != v.installationName {
+				return nil
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				vpnConnectionDesc,
+				prometheus.GaugeValue,
+				1,
+				to.String(connection.ID),
+				connectionName,
+				to.String(connection.Location),
+				string(connection.ConnectionType),
+				string(connection.ConnectionStatus),
+				string(connection.ProvisioningState),
+			)
+
+			return nil
+		})
+
+		if err := connections.NextWithContext(ctx); err != nil {
 			return microerror.Mask(err)
 		}
 	}
 
+	if err := g.Wait(); err != nil {
+		return microerror.Mask(err)
+	}
+
 	return nil
 }
 
@@ -58,15 +58,7 @@ func GetAzureConfigFromSecret(credential *v1.Secret, gsTenantID string) (*client
 		partnerID = ""
 	}
 
-	// By default we assume that the tenant cluster resources will belong to a subscription that belongs to a different Tenant ID than the one used for authentication.
-	// Typically this means we are using a Service Principal from the GiantSwarm Tenant ID.
-	credentials := auth.NewClientCredentialsConfig(clientID, clientSecret, tenantID)
-	credentials.AuxTenants = append(credentials.AuxTenants, gsTenantID)
-	if _, exists := credential.GetLabels()[SingleTenantSP]; exists || tenantID == gsTenantID {
-		// In this case the tenant cluster resources will belong to a subscription that belongs to the same Tenant ID used for authentication.
-		// Typically this means we are using a Service Principal from the customer Tenant ID.
-		credentials = auth.NewClientCredentialsConfig(clientID, clientSecret, tenantID)
-	}
+	credentials := newClientCredentialsConfig(credential, clientID, clientSecret, tenantID, gsTenantID)
 
 	authorizer, err := credentials.Authorizer()
 	if err != nil {
@@ -89,6 +81,25 @@ func GetAzureConfigFromSecret(credential *v1.Secret, gsTenantID string) (*client
 	return &azureClientSetConfig, nil
 }
 
+func newClientCredentialsConfig(credential *v1.Secret, clientID, clientSecret, tenantID, gsTenantID string) auth.ClientCredentialsConfig {
+	// By default we assume that the tenant cluster resources will belong to a subscription that belongs to a different Tenant ID than the one used for authentication.
+	// Typically this means we are using a Service Principal from the GiantSwarm Tenant ID.
+	credentials := auth.NewClientCredentialsConfig(clientID, clientSecret, tenantID)
+	credentials.AuxTenants = append(credentials.AuxTenants, gsTenantID)
+	if isSingleTenant(credential, tenantID, gsTenantID) {
+		// In this case the tenant cluster resources will belong to a subscription that belongs to the same Tenant ID used for authentication.
+		// Typically this means we are using a Service Principal from the customer Tenant ID.
+		credentials = auth.NewClientCredentialsConfig(clientID, clientSecret, tenantID)
+	}
+
+	return credentials
+}
+
+func isSingleTenant(credential *v1.Secret, tenantID, gsTenantID string) bool {
+	_, exists := credential.GetLabels()[SingleTenantSP]
+	return exists || tenantID == gsTenantID
+}
+
 func GetAzureClientSetsFromCredentialSecrets(ctx context.Context, ctrlClient ctrlclient.Client, gsTenantID string) (map[*client.AzureClientSetConfig]*client.AzureClientSet, error) {
 	azureClientSets := map[*client.AzureClientSetConfig]*client.AzureClientSet{}
 
@@ -0,0 +1,151 @@
+package credential
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/api/core/v1"
+)
+
+// RequiredKeys are the keys a credential secret must contain so that
+// GetAzureConfigFromSecret is able to build an Azure client set from it.
+var RequiredKeys = []string{
+	ClientIDKey,
+	ClientSecretKey,
+	SubscriptionIDKey,
+	TenantIDKey,
+}
+
+var aadErrorCodeRegexp = regexp.MustCompile(`AADSTS[0-9]+`)
+
+// SecretHealth describes how a credential secret is resolved by
+// GetAzureConfigFromSecret.
+type SecretHealth struct {
+	ClientID       string
+	SubscriptionID string
+	TenantID       string
+
+	// PresentKeys tells for each of the RequiredKeys whether it is set to a
+	// non-empty value in the secret.
+	PresentKeys map[string]bool
+	// PartnerIDFallback is true when the secret has no partner ID and the
+	// client set falls back to the default Azure GUID.
+	PartnerIDFallback bool
+	// SingleTenant is true when the secret is authenticated against its own
+	// tenant only, without the GiantSwarm tenant as auxiliary tenant.
+	SingleTenant bool
+}
+
+// GetSecretHealth inspects the given credential secret without talking to
+// Azure.
+func GetSecretHealth(credential *v1.Secret, gsTenantID string) SecretHealth {
+	h := SecretHealth{
+		PresentKeys: map[string]bool{},
+	}
+
+	for _, k := range RequiredKeys {
+		_, err := requiredValueFromSecret(credential, k)
+		h.PresentKeys[k] = err == nil
+	}
+
+	h.ClientID, _ = valueFromSecret(credential, ClientIDKey)
+	h.SubscriptionID, _ = valueFromSecret(credential, SubscriptionIDKey)
+	h.TenantID, _ = valueFromSecret(credential, TenantIDKey)
+
+	partnerID, _ := valueFromSecret(credential, PartnerIDKey)
+	h.PartnerIDFallback = partnerID == ""
+	h.SingleTenant = isSingleTenant(credential, h.TenantID, gsTenantID)
+
+	return h
+}
+
+// AcquireTokenFromSecret requests fresh access tokens for the service principal
+// stored in the given credential secret, for the primary tenant as well as for
+// the auxiliary tenants GetAzureConfigFromSecret would use.
+func AcquireTokenFromSecret(ctx context.Context, credential *v1.Secret, gsTenantID string) error {
+	clientID, err := requiredValueFromSecret(credential, ClientIDKey)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	clientSecret, err := requiredValueFromSecret(credential, ClientSecretKey)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	tenantID, err := requiredValueFromSecret(credential, TenantIDKey)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	credentials := newClientCredentialsConfig(credential, clientID, clientSecret, tenantID, gsTenantID)
+
+	if len(credentials.AuxTenants) == 0 {
+		token, err := credentials.ServicePrincipalToken()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = token.RefreshWithContext(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	tokens, err := credentials.MultiTenantServicePrincipalToken()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = tokens.PrimaryToken.RefreshWithContext(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, token := range tokens.AuxiliaryTokens {
+		err = token.RefreshWithContext(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// AADErrorCode extracts the Azure Active Directory error code, e.g.
+// AADSTS7000215 for an invalid client secret, from a token acquisition error.
+// It returns "unknown" when the error does not carry any AAD error code.
+func AADErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if IsMissingValue(err) {
+		return "MissingValue"
+	}
+
+	code := aadErrorCodeRegexp.FindString(err.Error())
+	if code == "" {
+		return "unknown"
+	}
+
+	return code
+}
+
+// requiredValueFromSecret works like valueFromSecret but also treats keys
+// which are present with an empty value as missing, since no token can be
+// acquired with them either.
+func requiredValueFromSecret(secret *v1.Secret, key string) (string, error) {
+	v, err := valueFromSecret(secret, key)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	if v == "" {
+		return "", microerror.Maskf(missingValueError, key)
+	}
+
+	return v, nil
+}
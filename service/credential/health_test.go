@@ -0,0 +1,198 @@
+package credential
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	testGSTenantID = "31f75bf9-3d8c-4691-95c0-83dd71613db8"
+)
+
+func Test_GetSecretHealth(t *testing.T) {
+	testCases := []struct {
+		name           string
+		secret         *v1.Secret
+		expectedHealth SecretHealth
+	}{
+		{
+			name: "case 0: complete multi tenant secret",
+			secret: &v1.Secret{
+				Data: map[string][]byte{
+					ClientIDKey:       []byte("client"),
+					ClientSecretKey:   []byte("secret"),
+					SubscriptionIDKey: []byte("subscription"),
+					TenantIDKey:       []byte("tenant"),
+					PartnerIDKey:      []byte("partner"),
+				},
+			},
+			expectedHealth: SecretHealth{
+				ClientID:       "client",
+				SubscriptionID: "subscription",
+				TenantID:       "tenant",
+				PresentKeys: map[string]bool{
+					ClientIDKey:       true,
+					ClientSecretKey:   true,
+					SubscriptionIDKey: true,
+					TenantIDKey:       true,
+				},
+			},
+		},
+		{
+			name: "case 1: secret without partner ID and client secret",
+			secret: &v1.Secret{
+				Data: map[string][]byte{
+					ClientIDKey:       []byte("client"),
+					SubscriptionIDKey: []byte("subscription"),
+					TenantIDKey:       []byte("tenant"),
+				},
+			},
+			expectedHealth: SecretHealth{
+				ClientID:       "client",
+				SubscriptionID: "subscription",
+				TenantID:       "tenant",
+				PresentKeys: map[string]bool{
+					ClientIDKey:       true,
+					ClientSecretKey:   false,
+					SubscriptionIDKey: true,
+					TenantIDKey:       true,
+				},
+				PartnerIDFallback: true,
+			},
+		},
+		{
+			name: "case 2: secret labeled as single tenant",
+			secret: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						SingleTenantSP: "true",
+					},
+				},
+				Data: map[string][]byte{
+					ClientIDKey:       []byte("client"),
+					ClientSecretKey:   []byte("secret"),
+					SubscriptionIDKey: []byte("subscription"),
+					TenantIDKey:       []byte("tenant"),
+					PartnerIDKey:      []byte("partner"),
+				},
+			},
+			expectedHealth: SecretHealth{
+				ClientID:       "client",
+				SubscriptionID: "subscription",
+				TenantID:       "tenant",
+				PresentKeys: map[string]bool{
+					ClientIDKey:       true,
+					ClientSecretKey:   true,
+					SubscriptionIDKey: true,
+					TenantIDKey:       true,
+				},
+				SingleTenant: true,
+			},
+		},
+		{
+			name: "case 3: secret in the GiantSwarm tenant",
+			secret: &v1.Secret{
+				Data: map[string][]byte{
+					ClientIDKey:       []byte("client"),
+					ClientSecretKey:   []byte("secret"),
+					SubscriptionIDKey: []byte("subscription"),
+					TenantIDKey:       []byte(testGSTenantID),
+					PartnerIDKey:      []byte("partner"),
+				},
+			},
+			expectedHealth: SecretHealth{
+				ClientID:       "client",
+				SubscriptionID: "subscription",
+				TenantID:       testGSTenantID,
+				PresentKeys: map[string]bool{
+					ClientIDKey:       true,
+					ClientSecretKey:   true,
+					SubscriptionIDKey: true,
+					TenantIDKey:       true,
+				},
+				SingleTenant: true,
+			},
+		},
+		{
+			name: "case 4: secret with empty client secret and tenant ID",
+			secret: &v1.Secret{
+				Data: map[string][]byte{
+					ClientIDKey:       []byte("client"),
+					ClientSecretKey:   []byte(""),
+					SubscriptionIDKey: []byte("subscription"),
+					TenantIDKey:       []byte(""),
+					PartnerIDKey:      []byte("partner"),
+				},
+			},
+			expectedHealth: SecretHealth{
+				ClientID:       "client",
+				SubscriptionID: "subscription",
+				PresentKeys: map[string]bool{
+					ClientIDKey:       true,
+					ClientSecretKey:   false,
+					SubscriptionIDKey: true,
+					TenantIDKey:       false,
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			health := GetSecretHealth(tc.secret, testGSTenantID)
+
+			if !cmp.Equal(health, tc.expectedHealth) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedHealth, health))
+			}
+		})
+	}
+}
+
+func Test_AADErrorCode(t *testing.T) {
+	testCases := []struct {
+		name         string
+		err          error
+		expectedCode string
+	}{
+		{
+			name:         "case 0: no error",
+			err:          nil,
+			expectedCode: "",
+		},
+		{
+			name:         "case 1: invalid client secret",
+			err:          microerror.Mask(errors.New(`adal: Refresh request failed. Status Code = '401'. Response body: {"error":"invalid_client","error_description":"AADSTS7000215: Invalid client secret provided."} Endpoint https://login.microsoftonline.com/tenant/oauth2/token`)),
+			expectedCode: "AADSTS7000215",
+		},
+		{
+			name:         "case 2: error without AAD error code",
+			err:          errors.New("dial tcp: i/o timeout"),
+			expectedCode: "unknown",
+		},
+		{
+			name:         "case 3: missing key in secret",
+			err:          microerror.Maskf(missingValueError, ClientSecretKey),
+			expectedCode: "MissingValue",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			code := AADErrorCode(tc.err)
+
+			if code != tc.expectedCode {
+				t.Fatalf("expected %#q got %#q", tc.expectedCode, code)
+			}
+		})
+	}
+}
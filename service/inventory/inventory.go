@@ -0,0 +1,235 @@
+// Package inventory keeps track of the clusters the collector discovered and of
+// the outcome of the collectors running for each of them, or for each
+// subscription in case of the collectors working on whole subscriptions.
+package inventory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// KindAzureConfig identifies vintage clusters backed by an AzureConfig CR.
+	KindAzureConfig = "AzureConfig"
+	// KindCluster identifies CAPI clusters backed by a Cluster CR.
+	KindCluster = "Cluster"
+)
+
+// Cluster is a cluster discovered by the collector.
+type Cluster struct {
+	ID               string `json:"id"`
+	Namespace        string `json:"namespace"`
+	Kind             string `json:"kind"`
+	CredentialSource string `json:"credentialSource"`
+	SubscriptionID   string `json:"subscriptionID"`
+	ResourceGroup    string `json:"resourceGroup"`
+	Location         string `json:"location"`
+	// Error is set when the cluster was found but its credentials could not be
+	// resolved.
+	Error string `json:"error,omitempty"`
+
+	Collectors map[string]CollectorStatus `json:"collectors"`
+}
+
+// Subscription is an Azure subscription the subscription wide collectors, like
+// usage or resource_group, ran for.
+type Subscription struct {
+	ID string `json:"id"`
+
+	Collectors map[string]CollectorStatus `json:"collectors"`
+}
+
+// CollectorStatus is the outcome of the latest runs of one collector for one
+// cluster.
+type CollectorStatus struct {
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastFailure *time.Time `json:"lastFailure,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// Inventory is safe for concurrent use.
+type Inventory struct {
+	mutex         sync.Mutex
+	clusters      map[string]*Cluster
+	subscriptions map[string]*Subscription
+	syncedAt      time.Time
+}
+
+func New() *Inventory {
+	i := &Inventory{
+		mutex:         sync.Mutex{},
+		clusters:      map[string]*Cluster{},
+		subscriptions: map[string]*Subscription{},
+	}
+
+	return i
+}
+
+// Sync replaces the discovered clusters. Collector statuses of clusters which
+// are still present are kept.
+func (i *Inventory) Sync(clusters []Cluster) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	synced := map[string]*Cluster{}
+	for _, c := range clusters {
+		cluster := c
+		cluster.Collectors = map[string]CollectorStatus{}
+
+		existing, ok := i.clusters[cluster.ID]
+		if ok {
+			for name, status := range existing.Collectors {
+				cluster.Collectors[name] = status
+			}
+		}
+
+		synced[cluster.ID] = &cluster
+	}
+
+	i.clusters = synced
+	i.syncedAt = time.Now()
+}
+
+// Record stores the outcome of a collector run for the given cluster. Runs for
+// clusters which have not been synced yet are tracked as well, so they show up
+// once the cluster is discovered.
+func (i *Inventory) Record(clusterID, collector string, err error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	cluster, ok := i.clusters[clusterID]
+	if !ok {
+		cluster = &Cluster{
+			ID:         clusterID,
+			Collectors: map[string]CollectorStatus{},
+		}
+		i.clusters[clusterID] = cluster
+	}
+
+	record(cluster.Collectors, collector, err)
+}
+
+// RecordSubscription stores the outcome of a collector run for the given
+// subscription.
+func (i *Inventory) RecordSubscription(subscriptionID, collector string, err error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	subscription, ok := i.subscriptions[subscriptionID]
+	if !ok {
+		subscription = &Subscription{
+			ID:         subscriptionID,
+			Collectors: map[string]CollectorStatus{},
+		}
+		i.subscriptions[subscriptionID] = subscription
+	}
+
+	record(subscription.Collectors, collector, err)
+}
+
+// Clusters returns a copy of all known clusters sorted by ID.
+func (i *Inventory) Clusters() []Cluster {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	var clusters []Cluster
+	for _, c := range i.clusters {
+		cluster := *c
+		cluster.Collectors = map[string]CollectorStatus{}
+		for name, status := range c.Collectors {
+			cluster.Collectors[name] = status
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Slice(clusters, func(a, b int) bool {
+		return clusters[a].ID < clusters[b].ID
+	})
+
+	return clusters
+}
+
+// Subscriptions returns a copy of all subscriptions collectors ran for sorted
+// by ID.
+func (i *Inventory) Subscriptions() []Subscription {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	var subscriptions []Subscription
+	for _, s := range i.subscriptions {
+		subscription := *s
+		subscription.Collectors = map[string]CollectorStatus{}
+		for name, status := range s.Collectors {
+			subscription.Collectors[name] = status
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	sort.Slice(subscriptions, func(a, b int) bool {
+		return subscriptions[a].ID < subscriptions[b].ID
+	})
+
+	return subscriptions
+}
+
+// Locations returns the sorted Azure locations of all known clusters, grouped by
+// the subscription they run in. Clusters without subscription or location are
+// skipped.
+func (i *Inventory) Locations() map[string][]string {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	seen := map[string]map[string]bool{}
+	for _, c := range i.clusters {
+		if c.SubscriptionID == "" || c.Location == "" {
+			continue
+		}
+
+		if seen[c.SubscriptionID] == nil {
+			seen[c.SubscriptionID] = map[string]bool{}
+		}
+		seen[c.SubscriptionID][NormalizeLocation(c.Location)] = true
+	}
+
+	locations := map[string][]string{}
+	for subscriptionID, l := range seen {
+		for location := range l {
+			locations[subscriptionID] = append(locations[subscriptionID], location)
+		}
+		sort.Strings(locations[subscriptionID])
+	}
+
+	return locations
+}
+
+// NormalizeLocation returns the name of an Azure location as used by the
+// Azure APIs, e.g. "westeurope" for "West Europe".
+func NormalizeLocation(location string) string {
+	return strings.ToLower(strings.ReplaceAll(location, " ", ""))
+}
+
+// SyncedAt returns the time of the latest Sync. It is zero as long as the
+// inventory has never been synced.
+func (i *Inventory) SyncedAt() time.Time {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	return i.syncedAt
+}
+
+func record(collectors map[string]CollectorStatus, collector string, err error) {
+	now := time.Now()
+	status := collectors[collector]
+	if err != nil {
+		status.LastFailure = &now
+		status.LastError = err.Error()
+	} else {
+		status.LastSuccess = &now
+		status.LastError = ""
+	}
+	collectors[collector] = status
+}
@@ -0,0 +1,106 @@
+package inventory
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_Inventory_Sync_KeepsCollectorStatus(t *testing.T) {
+	i := New()
+
+	if !i.SyncedAt().IsZero() {
+		t.Fatalf("expected inventory not to be synced")
+	}
+
+	i.Sync([]Cluster{{ID: "abc12"}, {ID: "def34"}})
+	i.Record("abc12", "deployment", nil)
+	i.Record("def34", "deployment", errors.New("boom"))
+
+	i.Sync([]Cluster{{ID: "abc12", SubscriptionID: "sub"}})
+
+	clusters := i.Clusters()
+	if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster got %d", len(clusters))
+	}
+	if clusters[0].SubscriptionID != "sub" {
+		t.Fatalf("expected subscription %#q got %#q", "sub", clusters[0].SubscriptionID)
+	}
+	status, ok := clusters[0].Collectors["deployment"]
+	if !ok || status.LastSuccess == nil {
+		t.Fatalf("expected collector status to be kept across syncs")
+	}
+	if i.SyncedAt().IsZero() {
+		t.Fatalf("expected inventory to be synced")
+	}
+}
+
+func Test_Inventory_Record_Failure(t *testing.T) {
+	i := New()
+
+	i.Record("abc12", "deployment", nil)
+	i.Record("abc12", "deployment", errors.New("boom"))
+
+	status := i.Clusters()[0].Collectors["deployment"]
+	if status.LastSuccess == nil || status.LastFailure == nil {
+		t.Fatalf("expected both success and failure to be tracked")
+	}
+	if status.LastError != "boom" {
+		t.Fatalf("expected error %#q got %#q", "boom", status.LastError)
+	}
+
+	i.Record("abc12", "deployment", nil)
+
+	status = i.Clusters()[0].Collectors["deployment"]
+	if status.LastError != "" {
+		t.Fatalf("expected error to be cleared after success got %#q", status.LastError)
+	}
+}
+
+func Test_Inventory_RecordSubscription(t *testing.T) {
+	i := New()
+
+	i.RecordSubscription("sub-b", "usage", nil)
+	i.RecordSubscription("sub-a", "usage", errors.New("boom"))
+	i.RecordSubscription("sub-a", "resource_group", nil)
+
+	subscriptions := i.Subscriptions()
+	if len(subscriptions) != 2 {
+		t.Fatalf("expected 2 subscriptions got %d", len(subscriptions))
+	}
+	if subscriptions[0].ID != "sub-a" {
+		t.Fatalf("expected subscriptions to be sorted by ID got %#q first", subscriptions[0].ID)
+	}
+	if subscriptions[0].Collectors["usage"].LastError != "boom" {
+		t.Fatalf("expected error %#q got %#q", "boom", subscriptions[0].Collectors["usage"].LastError)
+	}
+	if subscriptions[0].Collectors["resource_group"].LastSuccess == nil {
+		t.Fatalf("expected success of resource_group to be tracked")
+	}
+	if len(i.Clusters()) != 0 {
+		t.Fatalf("expected subscription records not to show up as clusters")
+	}
+}
+
+func Test_Inventory_Locations(t *testing.T) {
+	i := New()
+
+	i.Sync([]Cluster{
+		{ID: "abc12", SubscriptionID: "sub-a", Location: "westeurope"},
+		{ID: "def34", SubscriptionID: "sub-a", Location: "West Europe"},
+		{ID: "ghi56", SubscriptionID: "sub-a", Location: "germanywestcentral"},
+		{ID: "jkl78", SubscriptionID: "sub-b", Location: "westeurope"},
+		{ID: "mno90", SubscriptionID: "sub-b"},
+		{ID: "pqr12", Location: "westeurope"},
+	})
+
+	locations := i.Locations()
+	if len(locations) != 2 {
+		t.Fatalf("expected 2 subscriptions got %d", len(locations))
+	}
+	if len(locations["sub-a"]) != 2 || locations["sub-a"][0] != "germanywestcentral" || locations["sub-a"][1] != "westeurope" {
+		t.Fatalf("expected sorted unique locations for %#q got %v", "sub-a", locations["sub-a"])
+	}
+	if len(locations["sub-b"]) != 1 || locations["sub-b"][0] != "westeurope" {
+		t.Fatalf("expected %#q for %#q got %v", "westeurope", "sub-b", locations["sub-b"])
+	}
+}
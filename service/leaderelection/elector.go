@@ -0,0 +1,132 @@
+// Package leaderelection elects a single replica of the collector which is
+// allowed to poll the Azure APIs.
+package leaderelection
+
+import (
+	"context"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	MetricsNamespace = "azure_operator"
+
+	leaseDuration = 30 * time.Second
+	renewDeadline = 20 * time.Second
+	retryPeriod   = 5 * time.Second
+)
+
+var (
+	isLeaderGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: "leader_election",
+		Name:      "is_leader",
+		Help:      "Whether this replica is the leader polling the Azure APIs.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(isLeaderGauge)
+}
+
+type ElectorConfig struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// Identity identifies this replica, usually the pod name.
+	Identity  string
+	LeaseName string
+	Namespace string
+}
+
+// Elector takes part in the leader election using a Lease.
+type Elector struct {
+	logger  micrologger.Logger
+	elector *leaderelection.LeaderElector
+}
+
+func NewElector(config ElectorConfig) (*Elector, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Identity == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Identity must not be empty", config)
+	}
+	if config.LeaseName == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.LeaseName must not be empty", config)
+	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+
+	e := &Elector{
+		logger: config.Logger,
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      config.LeaseName,
+			Namespace: config.Namespace,
+		},
+		Client: config.K8sClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: config.Identity,
+		},
+	}
+
+	c := leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            config.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				e.logger.Debugf(ctx, "started leading as %#q", config.Identity)
+				isLeaderGauge.Set(1)
+			},
+			OnStoppedLeading: func() {
+				e.logger.Debugf(context.Background(), "stopped leading as %#q", config.Identity)
+				isLeaderGauge.Set(0)
+			},
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	e.elector = elector
+
+	return e, nil
+}
+
+// Boot takes part in the leader election until the given context is done.
+// Losing the leadership makes the replica a candidate again.
+func (e *Elector) Boot(ctx context.Context) {
+	for {
+		e.elector.Run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryPeriod):
+		}
+	}
+}
+
+// IsLeader returns whether this replica currently holds the lease.
+func (e *Elector) IsLeader() bool {
+	return e.elector.IsLeader()
+}
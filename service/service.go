@@ -2,22 +2,28 @@ package service
 
 import (
 	"context"
+	"os"
 	"sync"
 
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/k8sclient/v7/pkg/k8sclient"
 	"github.com/giantswarm/k8sclient/v7/pkg/k8srestconfig"
+	"github.com/giantswarm/microendpoint/service/healthz"
 	"github.com/giantswarm/microendpoint/service/version"
 	"github.com/giantswarm/microerror"
+	daemonflag "github.com/giantswarm/microkit/command/daemon/flag"
 	"github.com/giantswarm/micrologger"
 	"github.com/giantswarm/statusresource/v5"
 	"github.com/giantswarm/versionbundle"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
 	capzv1alpha3 "sigs.k8s.io/cluster-api-provider-azure/api/v1beta1"
+	capzexpv1beta1 "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1beta1"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	kcpv1beta1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1beta1"
 	capiv1alpha4 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	capiexpv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -25,13 +31,19 @@ import (
 	"github.com/giantswarm/azure-collector/v3/flag"
 	"github.com/giantswarm/azure-collector/v3/pkg/project"
 	"github.com/giantswarm/azure-collector/v3/service/collector"
+	"github.com/giantswarm/azure-collector/v3/service/inventory"
+	"github.com/giantswarm/azure-collector/v3/service/leaderelection"
+	"github.com/giantswarm/azure-collector/v3/service/sharding"
 )
 
 // Config represents the configuration used to create a new service.
 type Config struct {
 	Logger micrologger.Logger
 
-	Flag  *flag.Flag
+	Flag *flag.Flag
+	// Flags is optional. When set, the reloadable settings given as command
+	// line flags take precedence over the config files and the ConfigMap.
+	Flags *pflag.FlagSet
 	Viper *viper.Viper
 
 	Description string
@@ -42,10 +54,16 @@ type Config struct {
 }
 
 type Service struct {
-	Version *version.Service
+	Inventory *inventory.Inventory
+	Liveness  healthz.Service
+	Readiness healthz.Service
+	Version   *version.Service
 
 	bootOnce                sync.Once
+	elector                 *leaderelection.Elector
 	operatorCollector       *collector.Set
+	ring                    *sharding.Ring
+	settingsWatcher         *settingsWatcher
 	statusResourceCollector *statusresource.CollectorSet
 }
 
@@ -76,6 +94,17 @@ func New(config Config) (*Service, error) {
 
 	var err error
 
+	// The config files are merged on top of the command line flags. Flags
+	// given explicitly take precedence for the reloadable settings, at startup
+	// as well as on every reload.
+	explicitFlags, err := explicitFlagValues(config.Flags, reloadableKeys(config.Flag))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	for k, v := range explicitFlags {
+		config.Viper.Set(k, v)
+	}
+
 	var k8sClient *k8sclient.Clients
 	{
 		address := config.Viper.GetString(config.Flag.Service.Kubernetes.Address)
@@ -115,7 +144,9 @@ func New(config Config) (*Service, error) {
 				capiv1beta1.AddToScheme,
 				capiexpv1beta1.AddToScheme,
 				capzv1alpha3.AddToScheme,
+				capzexpv1beta1.AddToScheme,
 				capiv1alpha4.AddToScheme,
+				kcpv1beta1.AddToScheme,
 			},
 
 			KubeConfigPath: kubeConfigPath,
@@ -128,22 +159,113 @@ func New(config Config) (*Service, error) {
 		}
 	}
 
+	leaderElectionEnabled := config.Viper.GetBool(config.Flag.Service.LeaderElection.Enabled)
+	shardingEnabled := config.Viper.GetBool(config.Flag.Service.Sharding.Enabled)
+	if leaderElectionEnabled && shardingEnabled {
+		return nil, microerror.Maskf(invalidConfigError, "leader election and sharding must not be enabled at the same time")
+	}
+
+	var identity string
+	if leaderElectionEnabled || shardingEnabled {
+		identity, err = os.Hostname()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var elector *leaderelection.Elector
+	if leaderElectionEnabled {
+		c := leaderelection.ElectorConfig{
+			K8sClient: k8sClient.K8sClient(),
+			Logger:    config.Logger,
+
+			Identity:  identity,
+			LeaseName: config.Viper.GetString(config.Flag.Service.LeaderElection.LeaseName),
+			Namespace: config.Viper.GetString(config.Flag.Service.LeaderElection.Namespace),
+		}
+
+		elector, err = leaderelection.NewElector(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var ring *sharding.Ring
+	var shard sharding.Interface = sharding.All{}
+	if shardingEnabled {
+		c := sharding.RingConfig{
+			K8sClient: k8sClient.K8sClient(),
+			Logger:    config.Logger,
+
+			Identity:    identity,
+			LeasePrefix: config.Viper.GetString(config.Flag.Service.Sharding.LeasePrefix),
+			Namespace:   config.Viper.GetString(config.Flag.Service.Sharding.Namespace),
+		}
+
+		ring, err = sharding.NewRing(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		shard = ring
+	}
+
+	clusterInventory := inventory.New()
+
 	var operatorCollector *collector.Set
 	{
 		c := collector.SetConfig{
+			Inventory:                 clusterInventory,
 			ControlPlaneResourceGroup: config.Viper.GetString(config.Flag.Service.ControlPlaneResourceGroup),
+			DisabledCollectors:        config.Viper.GetStringSlice(config.Flag.Service.Collectors.Disabled),
+			HistogramsConfigMap:       config.Viper.GetString(config.Flag.Service.Histograms.ConfigMapName),
+			HistogramsNamespace:       config.Viper.GetString(config.Flag.Service.Histograms.ConfigMapNamespace),
+			LoadBalancerNames:         config.Viper.GetStringSlice(config.Flag.Service.LoadBalancer.Names),
 			Location:                  config.Viper.GetString(config.Flag.Service.Location),
 			Logger:                    config.Logger,
 			K8sClient:                 k8sClient,
+			ResourceGroupTagKeys:      config.Viper.GetStringSlice(config.Flag.Service.ResourceGroup.TagKeys),
+			Shard:                     shard,
+			UsageLocations:            config.Viper.GetStringSlice(config.Flag.Service.Usage.Locations),
 			GSTenantID:                config.Viper.GetString(config.Flag.Service.Azure.TenantID),
 		}
 
+		// A nil *Elector must not end up in the interface, otherwise the
+		// collectors would consider leader election enabled.
+		if elector != nil {
+			c.Leader = elector
+		}
+
 		operatorCollector, err = collector.NewSet(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 	}
 
+	var watcher *settingsWatcher
+	{
+		f := daemonflag.New()
+
+		c := settingsWatcherConfig{
+			Flag:      config.Flag,
+			K8sClient: k8sClient.K8sClient(),
+			Logger:    config.Logger,
+			Set:       operatorCollector,
+			Viper:     config.Viper,
+
+			ConfigDirs:         config.Viper.GetStringSlice(f.Config.Dirs),
+			ConfigFiles:        config.Viper.GetStringSlice(f.Config.Files),
+			ConfigMapName:      config.Viper.GetString(config.Flag.Service.Reload.ConfigMapName),
+			ConfigMapNamespace: config.Viper.GetString(config.Flag.Service.Reload.ConfigMapNamespace),
+			Interval:           config.Viper.GetDuration(config.Flag.Service.Reload.Interval),
+			ExplicitFlags:      explicitFlags,
+		}
+
+		watcher, err = newSettingsWatcher(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var statusResourceCollector *statusresource.CollectorSet
 	{
 		f := func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
@@ -185,10 +307,16 @@ func New(config Config) (*Service, error) {
 	}
 
 	s := &Service{
-		Version: versionService,
+		Inventory: clusterInventory,
+		Liveness:  operatorCollector.LivenessProbe(),
+		Readiness: operatorCollector.ReadinessProbe(),
+		Version:   versionService,
 
 		bootOnce:                sync.Once{},
+		elector:                 elector,
 		operatorCollector:       operatorCollector,
+		ring:                    ring,
+		settingsWatcher:         watcher,
 		statusResourceCollector: statusResourceCollector,
 	}
 
@@ -197,7 +325,15 @@ func New(config Config) (*Service, error) {
 
 func (s *Service) Boot(ctx context.Context) {
 	s.bootOnce.Do(func() {
-		go s.operatorCollector.Boot(ctx)       // nolint: errcheck
+		if s.elector != nil {
+			go s.elector.Boot(ctx)
+		}
+		if s.ring != nil {
+			go s.ring.Boot(ctx)
+		}
+
+		go s.operatorCollector.Boot(ctx) // nolint: errcheck
+		go s.settingsWatcher.Boot(ctx)
 		go s.statusResourceCollector.Boot(ctx) // nolint: errcheck
 	})
 }
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/azure-collector/v3/flag"
+	"github.com/giantswarm/azure-collector/v3/service/collector"
+)
+
+const (
+	// configMapKey is the key of the ConfigMap holding the config file.
+	configMapKey = "config.yaml"
+)
+
+type settingsWatcherConfig struct {
+	Flag      *flag.Flag
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+	Set       *collector.Set
+	Viper     *viper.Viper
+
+	ConfigDirs  []string
+	ConfigFiles []string
+	// ConfigMapName is optional. When set, the ConfigMap is polled for
+	// overrides of the config files. It must not be the ConfigMap mounted as
+	// config file, which is already watched.
+	ConfigMapName      string
+	ConfigMapNamespace string
+	Interval           time.Duration
+	// ExplicitFlags is optional. It holds the settings given as command line
+	// flags, which take precedence over the config files and the ConfigMap.
+	ExplicitFlags map[string]interface{}
+}
+
+// settingsWatcher applies the settings of the collector set whenever the
+// config files or the ConfigMap of the service change, so that they can be
+// tuned without a rollout.
+type settingsWatcher struct {
+	flag      *flag.Flag
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+	set       *collector.Set
+
+	configDirs         []string
+	configFiles        []string
+	configMapName      string
+	configMapNamespace string
+	interval           time.Duration
+
+	mutex sync.Mutex
+	// initial holds the settings the service has been started with. sources
+	// holds the settings read from every config file followed by the ones read
+	// from the ConfigMap. explicitFlags holds the settings given as command
+	// line flags. They are applied in that order.
+	initial       map[string]interface{}
+	sources       []map[string]interface{}
+	explicitFlags map[string]interface{}
+}
+
+func newSettingsWatcher(config settingsWatcherConfig) (*settingsWatcher, error) {
+	if config.Flag == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Flag must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Set == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Set must not be empty", config)
+	}
+	if config.Viper == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Viper must not be empty", config)
+	}
+	if config.ConfigMapName != "" && config.ConfigMapNamespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ConfigMapNamespace must not be empty when %T.ConfigMapName is set", config, config)
+	}
+	if config.ConfigMapName != "" && config.Interval <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Interval must be positive when %T.ConfigMapName is set", config, config)
+	}
+
+	w := &settingsWatcher{
+		flag:      config.Flag,
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+		set:       config.Set,
+
+		configDirs:         config.ConfigDirs,
+		configFiles:        config.ConfigFiles,
+		configMapName:      config.ConfigMapName,
+		configMapNamespace: config.ConfigMapNamespace,
+		interval:           config.Interval,
+
+		mutex:         sync.Mutex{},
+		sources:       make([]map[string]interface{}, len(config.ConfigFiles)+1),
+		explicitFlags: config.ExplicitFlags,
+	}
+	w.initial = w.read(config.Viper)
+
+	return w, nil
+}
+
+// Boot watches the config files and polls the ConfigMap until the given
+// context is done.
+func (w *settingsWatcher) Boot(ctx context.Context) {
+	for i, f := range w.configFiles {
+		v := viper.New()
+		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		v.AutomaticEnv()
+		for _, d := range w.configDirs {
+			v.AddConfigPath(d)
+		}
+		v.SetConfigName(f)
+
+		err := v.ReadInConfig()
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			continue
+		} else if err != nil {
+			w.logger.Errorf(ctx, err, "failed to read config file %#q", f)
+			continue
+		}
+
+		source := i
+		v.OnConfigChange(func(e fsnotify.Event) {
+			w.logger.Debugf(ctx, "config file %#q changed", e.Name)
+			w.update(ctx, source, w.read(v))
+		})
+		v.WatchConfig()
+	}
+
+	if w.configMapName == "" {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		values, err := w.readConfigMap(ctx)
+		if err != nil {
+			w.logger.Errorf(ctx, err, "failed to read ConfigMap %#q in namespace %#q", w.configMapName, w.configMapNamespace)
+			continue
+		}
+
+		w.update(ctx, len(w.configFiles), values)
+	}
+}
+
+func (w *settingsWatcher) readConfigMap(ctx context.Context) (map[string]interface{}, error) {
+	cm, err := w.k8sClient.CoreV1().ConfigMaps(w.configMapNamespace).Get(ctx, w.configMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err = v.ReadConfig(strings.NewReader(cm.Data[configMapKey]))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return w.read(v), nil
+}
+
+// read returns the values of all reloadable settings set in the given viper.
+func (w *settingsWatcher) read(v *viper.Viper) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, k := range reloadableKeys(w.flag) {
+		if v.IsSet(k) {
+			values[k] = v.Get(k)
+		}
+	}
+
+	return values
+}
+
+func (w *settingsWatcher) update(ctx context.Context, source int, values map[string]interface{}) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.sources[source] = values
+
+	merged := viper.New()
+	for k, v := range w.initial {
+		merged.Set(k, v)
+	}
+	for _, s := range w.sources {
+		for k, v := range s {
+			merged.Set(k, v)
+		}
+	}
+	for k, v := range w.explicitFlags {
+		merged.Set(k, v)
+	}
+
+	settings := collector.Settings{
+		ControlPlaneResourceGroup: merged.GetString(w.flag.Service.ControlPlaneResourceGroup),
+		GSTenantID:                merged.GetString(w.flag.Service.Azure.TenantID),
+		Location:                  merged.GetString(w.flag.Service.Location),
+		DisabledCollectors:        merged.GetStringSlice(w.flag.Service.Collectors.Disabled),
+		LoadBalancerNames:         merged.GetStringSlice(w.flag.Service.LoadBalancer.Names),
+		ResourceGroupTagKeys:      merged.GetStringSlice(w.flag.Service.ResourceGroup.TagKeys),
+		UsageLocations:            merged.GetStringSlice(w.flag.Service.Usage.Locations),
+	}
+
+	err := w.set.Reload(settings)
+	if err != nil {
+		w.logger.Errorf(ctx, err, "failed to apply settings")
+	}
+}
+
+// reloadableKeys returns the settings which are applied at runtime. All other
+// settings, e.g. leader election or sharding, still require a restart.
+func reloadableKeys(f *flag.Flag) []string {
+	return []string{
+		f.Service.ControlPlaneResourceGroup,
+		f.Service.Azure.TenantID,
+		f.Service.Collectors.Disabled,
+		f.Service.LoadBalancer.Names,
+		f.Service.Location,
+		f.Service.ResourceGroup.TagKeys,
+		f.Service.Usage.Locations,
+	}
+}
+
+// explicitFlagValues returns the values of the given settings which were set
+// explicitly as command line flags.
+func explicitFlagValues(flags *pflag.FlagSet, keys []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if flags == nil {
+		return values, nil
+	}
+
+	v := viper.New()
+	err := v.BindPFlags(flags)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, k := range keys {
+		f := flags.Lookup(k)
+		if f != nil && f.Changed {
+			values[k] = v.Get(k)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/pflag"
+
+	"github.com/giantswarm/azure-collector/v3/flag"
+)
+
+func Test_explicitFlagValues(t *testing.T) {
+	f := flag.New()
+
+	flags := pflag.NewFlagSet("daemon", pflag.ContinueOnError)
+	flags.String(f.Service.Location, "westeurope", "")
+	flags.String(f.Service.ControlPlaneResourceGroup, "", "")
+	flags.StringSlice(f.Service.Usage.Locations, nil, "")
+
+	err := flags.Parse([]string{"--" + f.Service.Location + "=germanywestcentral", "--" + f.Service.Usage.Locations + "=westeurope,northeurope"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := explicitFlagValues(flags, reloadableKeys(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		f.Service.Location:        "germanywestcentral",
+		f.Service.Usage.Locations: []string{"westeurope", "northeurope"},
+	}
+	if !cmp.Equal(values, expected) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, values))
+	}
+}
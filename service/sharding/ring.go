@@ -0,0 +1,69 @@
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+const (
+	// virtualNodes is the number of points each member gets on the ring. More
+	// points spread the keys more evenly across members.
+	virtualNodes = 64
+)
+
+// ring is a consistent hash ring. Adding or removing a member only moves the
+// keys of that member.
+type ring struct {
+	hashes  []uint32
+	members map[uint32]string
+}
+
+func newRing(members []string) *ring {
+	r := &ring{
+		members: map[uint32]string{},
+	}
+
+	for _, m := range members {
+		for i := 0; i < virtualNodes; i++ {
+			h := hash(m + "-" + strconv.Itoa(i))
+			r.hashes = append(r.hashes, h)
+			r.members[h] = m
+		}
+	}
+
+	sort.Slice(r.hashes, func(a, b int) bool {
+		return r.hashes[a] < r.hashes[b]
+	})
+
+	return r
+}
+
+// get returns the member owning the given key. It returns an empty string if
+// the ring has no members.
+func (r *ring) get(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	h := hash(key)
+	i := sort.Search(len(r.hashes), func(i int) bool {
+		return r.hashes[i] >= h
+	})
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.members[r.hashes[i]]
+}
+
+func hash(s string) uint32 {
+	h := fnv.New32a()
+	_, err := h.Write([]byte(s))
+	if err != nil {
+		panic(fmt.Sprintf("hashing %#q: %s", s, err))
+	}
+
+	return h.Sum32()
+}
@@ -0,0 +1,66 @@
+package sharding
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_Ring_Get(t *testing.T) {
+	testCases := []struct {
+		name    string
+		members []string
+	}{
+		{
+			name:    "case 0: single member owns all keys",
+			members: []string{"a"},
+		},
+		{
+			name:    "case 1: keys are spread across three members",
+			members: []string{"a", "b", "c"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			r := newRing(tc.members)
+
+			owned := map[string]int{}
+			for k := 0; k < 1000; k++ {
+				owned[r.get("cluster-"+strconv.Itoa(k))]++
+			}
+
+			for _, m := range tc.members {
+				if owned[m] == 0 {
+					t.Fatalf("expected member %#q to own keys", m)
+				}
+			}
+			if len(owned) != len(tc.members) {
+				t.Fatalf("expected %d owners, got %d", len(tc.members), len(owned))
+			}
+		})
+	}
+}
+
+func Test_Ring_Stability(t *testing.T) {
+	before := newRing([]string{"a", "b", "c"})
+	after := newRing([]string{"a", "b", "c", "d"})
+
+	for k := 0; k < 1000; k++ {
+		key := "cluster-" + strconv.Itoa(k)
+
+		owner := after.get(key)
+		if owner != "d" && owner != before.get(key) {
+			t.Fatalf("expected key %#q to stay with %#q, moved to %#q", key, before.get(key), owner)
+		}
+	}
+}
+
+func Test_Ring_Empty(t *testing.T) {
+	r := newRing(nil)
+
+	if r.get("cluster") != "" {
+		t.Fatalf("expected no owner for an empty ring")
+	}
+}
@@ -0,0 +1,282 @@
+// Package sharding distributes clusters and subscriptions across the replicas
+// of the collector, so that every replica only polls the Azure APIs for its
+// own share.
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	MetricsNamespace = "azure_operator"
+
+	leaseDuration = 60 * time.Second
+	renewPeriod   = 15 * time.Second
+
+	// leaseGCAfter is how long a member lease has to be expired before it is
+	// deleted, e.g. when a replica was killed before it was able to delete its
+	// own lease.
+	leaseGCAfter = 10 * leaseDuration
+	// leaveTimeout bounds the deletion of the member lease on shutdown.
+	leaveTimeout = 2 * time.Second
+
+	// memberLabel is set on the member leases to the lease prefix, so that
+	// members of different collectors can share a namespace.
+	memberLabel = "azure-collector.giantswarm.io/shard-member"
+)
+
+var (
+	membersGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: "sharding",
+		Name:      "members",
+		Help:      "Number of replicas sharing the clusters and subscriptions.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(membersGauge)
+}
+
+// Interface decides whether a cluster or subscription is handled by this
+// replica.
+type Interface interface {
+	// Owns returns true if the given cluster ID or subscription ID belongs to
+	// the share of this replica.
+	Owns(key string) bool
+}
+
+// All owns every key. It is used when sharding is disabled.
+type All struct{}
+
+func (All) Owns(key string) bool {
+	return true
+}
+
+type RingConfig struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// Identity identifies this replica, usually the pod name.
+	Identity    string
+	LeasePrefix string
+	Namespace   string
+}
+
+// Ring shares keys across all replicas which keep a member Lease up to date,
+// using consistent hashing.
+type Ring struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	identity    string
+	leasePrefix string
+	namespace   string
+
+	mutex sync.RWMutex
+	ring  *ring
+}
+
+func NewRing(config RingConfig) (*Ring, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Identity == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Identity must not be empty", config)
+	}
+	if config.LeasePrefix == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.LeasePrefix must not be empty", config)
+	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+
+	r := &Ring{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		identity:    config.Identity,
+		leasePrefix: config.LeasePrefix,
+		namespace:   config.Namespace,
+
+		mutex: sync.RWMutex{},
+		// Until the members are known this replica owns everything, so no
+		// metrics are missing right after start.
+		ring: newRing([]string{config.Identity}),
+	}
+
+	return r, nil
+}
+
+// Boot keeps the member lease of this replica up to date and refreshes the
+// members of the ring until the given context is done. The member lease is
+// deleted then, so the other replicas take over its share right away.
+func (r *Ring) Boot(ctx context.Context) {
+	ticker := time.NewTicker(renewPeriod)
+	defer ticker.Stop()
+
+	for {
+		err := r.sync(ctx)
+		if err != nil {
+			r.logger.Errorf(ctx, err, "failed to sync shard members")
+		}
+
+		select {
+		case <-ctx.Done():
+			leaveCtx, cancel := context.WithTimeout(context.Background(), leaveTimeout)
+			err := r.leave(leaveCtx)
+			if err != nil {
+				r.logger.Errorf(leaveCtx, err, "failed to delete shard member lease")
+			}
+			cancel()
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Ring) Owns(key string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.ring.get(key) == r.identity
+}
+
+func (r *Ring) sync(ctx context.Context) error {
+	err := r.renew(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	members, err := r.members(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.mutex.Lock()
+	r.ring = newRing(members)
+	r.mutex.Unlock()
+
+	membersGauge.Set(float64(len(members)))
+
+	return nil
+}
+
+func (r *Ring) renew(ctx context.Context) error {
+	leases := r.k8sClient.CoordinationV1().Leases(r.namespace)
+	name := r.leaseName()
+	now := metav1.NewMicroTime(time.Now())
+
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: r.namespace,
+				Labels: map[string]string{
+					memberLabel: r.leasePrefix,
+				},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       to.StringPtr(r.identity),
+				LeaseDurationSeconds: to.Int32Ptr(int32(leaseDuration.Seconds())),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+
+		_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	lease.Spec.HolderIdentity = to.StringPtr(r.identity)
+	lease.Spec.LeaseDurationSeconds = to.Int32Ptr(int32(leaseDuration.Seconds()))
+	lease.Spec.RenewTime = &now
+
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// leave deletes the member lease of this replica.
+func (r *Ring) leave(ctx context.Context) error {
+	err := r.k8sClient.CoordinationV1().Leases(r.namespace).Delete(ctx, r.leaseName(), metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *Ring) leaseName() string {
+	return fmt.Sprintf("%s-%s", r.leasePrefix, r.identity)
+}
+
+// members returns the identities of all replicas whose member lease has not
+// expired yet, always including this replica. Leases which expired more than
+// leaseGCAfter ago are deleted.
+func (r *Ring) members(ctx context.Context) ([]string, error) {
+	leases := r.k8sClient.CoordinationV1().Leases(r.namespace)
+	list, err := leases.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", memberLabel, r.leasePrefix),
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	members := []string{r.identity}
+	for _, lease := range list.Items {
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == r.identity {
+			continue
+		}
+		if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+			continue
+		}
+
+		expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+		if time.Since(expiry) > leaseGCAfter {
+			err := leases.Delete(ctx, lease.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				r.logger.Errorf(ctx, err, "failed to delete expired shard member lease %#q", lease.Name)
+			}
+			continue
+		}
+		if time.Now().After(expiry) {
+			continue
+		}
+
+		members = append(members, *lease.Spec.HolderIdentity)
+	}
+
+	sort.Strings(members)
+
+	return members, nil
+}
@@ -0,0 +1,88 @@
+package sharding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/giantswarm/micrologger/microloggertest"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Ring_Sync_DeletesStaleLeases(t *testing.T) {
+	memberLease := func(identity string, renewed time.Time) *coordinationv1.Lease {
+		renewTime := metav1.NewMicroTime(renewed)
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "azure-collector-" + identity,
+				Namespace: "giantswarm",
+				Labels: map[string]string{
+					memberLabel: "azure-collector",
+				},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       to.StringPtr(identity),
+				LeaseDurationSeconds: to.Int32Ptr(int32(leaseDuration.Seconds())),
+				RenewTime:            &renewTime,
+			},
+		}
+	}
+
+	k8sClient := fake.NewSimpleClientset(
+		memberLease("alive", time.Now()),
+		memberLease("expired", time.Now().Add(-2*leaseDuration)),
+		memberLease("stale", time.Now().Add(-2*leaseGCAfter)),
+	)
+
+	r, err := NewRing(RingConfig{
+		K8sClient:   k8sClient,
+		Logger:      microloggertest.New(),
+		Identity:    "self",
+		LeasePrefix: "azure-collector",
+		Namespace:   "giantswarm",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	err = r.sync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := r.members(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 || members[0] != "alive" || members[1] != "self" {
+		t.Fatalf("expected members %v got %v", []string{"alive", "self"}, members)
+	}
+
+	leases := k8sClient.CoordinationV1().Leases("giantswarm")
+	for name, expected := range map[string]bool{
+		"azure-collector-alive":   true,
+		"azure-collector-expired": true,
+		"azure-collector-self":    true,
+		"azure-collector-stale":   false,
+	} {
+		_, err := leases.Get(ctx, name, metav1.GetOptions{})
+		if exists := err == nil; exists != expected {
+			t.Fatalf("expected lease %#q to exist %t", name, expected)
+		}
+	}
+
+	err = r.leave(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = leases.Get(ctx, "azure-collector-self", metav1.GetOptions{})
+	if err == nil {
+		t.Fatalf("expected own lease to be deleted when leaving")
+	}
+}